@@ -0,0 +1,249 @@
+package postgrestest
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// AssertEmptySchema fails the test if the public schema of the database contains
+// any table, sequence or view. Objects installed by extensions are ignored.
+// It can be used to make sure a database cloned from a template started clean,
+// or that truncating it removed everything.
+func AssertEmptySchema(t TestingT, db *sql.DB) {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	rows, err := db.Query(`SELECT c.relname,
+	CASE c.relkind
+		WHEN 'S' THEN 'sequence'
+		WHEN 'v' THEN 'view'
+		WHEN 'm' THEN 'materialized view'
+		WHEN 'f' THEN 'foreign table'
+		ELSE 'table'
+	END
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = 'public'
+  AND c.relkind IN ('r', 'p', 'S', 'v', 'm', 'f')
+  AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.classid = 'pg_class'::regclass AND d.objid = c.oid AND d.deptype = 'e')
+ORDER BY c.relname;`)
+	require.NoError(t, err)
+	defer rows.Close()
+	var objects []string
+	for rows.Next() {
+		var name, kind string
+		err := rows.Scan(&name, &kind)
+		require.NoError(t, err)
+		objects = append(objects, kind+" "+name)
+	}
+	require.NoError(t, rows.Err())
+	require.Empty(t, objects, "the public schema is not empty")
+}
+
+// AssertRowCount fails the test if the table, optionally schema qualified like
+// billing.invoices, doesn't have n rows.
+func AssertRowCount(t TestingT, db *sql.DB, table string, n int) {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	count := RequireQueryInt(t, db, `SELECT count(*) FROM `+quoteQualifiedIdentifier(table)+`;`)
+	require.Equal(t, n, count, "unexpected number of rows on %s", table)
+}
+
+// AssertRowExists fails the test if the table, optionally schema qualified like
+// billing.invoices, has no row with the values on the columns. Nil values match NULL.
+//
+//	postgrestest.AssertRowExists(t, db, "users", map[string]interface{}{
+//		"email":      "alice@example.com",
+//		"deleted_at": nil,
+//	})
+func AssertRowExists(t TestingT, db *sql.DB, table string, values map[string]interface{}) {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	conditions := []string{"true"}
+	var args []interface{}
+	for _, column := range columns {
+		if values[column] == nil {
+			conditions = append(conditions, quoteIdentifier(column)+` IS NULL`)
+			continue
+		}
+		args = append(args, values[column])
+		conditions = append(conditions, fmt.Sprintf(`%s = $%d`, quoteIdentifier(column), len(args)))
+	}
+	var exists bool
+	err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM `+quoteQualifiedIdentifier(table)+` WHERE `+strings.Join(conditions, ` AND `)+`);`, args...).Scan(&exists)
+	require.NoError(t, err)
+	if !exists {
+		require.Fail(t, fmt.Sprintf("no row on %s with the values %v", table, values))
+	}
+}
+
+// RequireQueryInt returns the integer of the single column of the first row returned
+// by the query, failing the test if it fails or returns no rows.
+func RequireQueryInt(t TestingT, db *sql.DB, query string, args ...interface{}) int {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	var result int
+	require.NoError(t, db.QueryRow(query, args...).Scan(&result), "failed to query %s", query)
+	return result
+}
+
+// RequireQueryString returns the string of the single column of the first row returned
+// by the query, failing the test if it fails or returns no rows.
+func RequireQueryString(t TestingT, db *sql.DB, query string, args ...interface{}) string {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	var result string
+	require.NoError(t, db.QueryRow(query, args...).Scan(&result), "failed to query %s", query)
+	return result
+}
+
+// AssertTableEquals fails the test if the rows of the table, optionally schema qualified
+// like billing.invoices, aren't the expected ones, in any order, printing the rows
+// missing with - and the unexpected ones with +. Only the columns of the expected rows
+// are compared, they must all have the same ones:
+//
+//	postgrestest.AssertTableEquals(t, db, "orders", []map[string]interface{}{
+//		{"id": 1, "status": "paid", "cancelled_at": nil},
+//		{"id": 2, "status": "pending", "cancelled_at": nil},
+//	})
+//
+// The values are compared by their text, quoted on the diff, so 1 matches a bigint
+// column and "1.50" a numeric one. Times are formatted as RFC 3339 with nanoseconds in
+// UTC, the zone of the driver doesn't matter. Nil matches NULL, printed unquoted, so
+// it doesn't match the text "NULL". No expected rows asserts the table is empty.
+func AssertTableEquals(t TestingT, db *sql.DB, table string, expected []map[string]interface{}) {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	if len(expected) == 0 {
+		AssertRowCount(t, db, table, 0)
+		return
+	}
+	columns := make([]string, 0, len(expected[0]))
+	for column := range expected[0] {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	want := make([]string, 0, len(expected))
+	for i, row := range expected {
+		values := make([]interface{}, len(columns))
+		for j, column := range columns {
+			value, ok := row[column]
+			if !ok || len(row) != len(columns) {
+				require.Fail(t, fmt.Sprintf("the expected row %d doesn't have the columns %s of the first one", i, strings.Join(columns, ", ")))
+			}
+			values[j] = value
+		}
+		want = append(want, formatRow(columns, values))
+	}
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = quoteIdentifier(column)
+	}
+	rows, err := db.Query(`SELECT ` + strings.Join(quoted, `, `) + ` FROM ` + quoteQualifiedIdentifier(table) + `;`)
+	require.NoError(t, err)
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		require.NoError(t, rows.Scan(pointers...))
+		got = append(got, formatRow(columns, values))
+	}
+	require.NoError(t, rows.Err())
+	if diff := rowsDiff(want, got); diff != "" {
+		require.Fail(t, fmt.Sprintf("the rows of %s aren't the expected ones (-want +got):\n%s", table, diff))
+	}
+}
+
+// formatRow formats the values of the columns of a row, like {id: "1", status: "paid"}.
+func formatRow(columns []string, values []interface{}) string {
+	fields := make([]string, len(columns))
+	for i, column := range columns {
+		fields[i] = column + ": " + formatValue(values[i])
+	}
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+// formatValue returns the quoted text of a value, or NULL unquoted for nil.
+func formatValue(value interface{}) string {
+	text, ok := valueText(value)
+	if !ok {
+		return "NULL"
+	}
+	return strconv.Quote(text)
+}
+
+// valueText returns the text used to compare a value, false for nil. Times are in UTC
+// so the text doesn't depend on the zone of the driver.
+func valueText(value interface{}) (string, bool) {
+	switch value := value.(type) {
+	case nil:
+		return "", false
+	case []byte:
+		return string(value), true
+	case time.Time:
+		return value.UTC().Format(time.RFC3339Nano), true
+	default:
+		return fmt.Sprint(value), true
+	}
+}
+
+// rowsDiff returns the rows missing from got prefixed with -, and the unexpected ones
+// prefixed with +, sorted, empty when they have the same rows in any order.
+func rowsDiff(want, got []string) string {
+	counts := make(map[string]int)
+	for _, row := range want {
+		counts[row]++
+	}
+	for _, row := range got {
+		counts[row]--
+	}
+	var lines []string
+	for row, count := range counts {
+		for ; count > 0; count-- {
+			lines = append(lines, "- "+row)
+		}
+		for ; count < 0; count++ {
+			lines = append(lines, "+ "+row)
+		}
+	}
+	// the rows are sorted with the missing ones next to the unexpected ones like them
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i][2:] != lines[j][2:] {
+			return lines[i][2:] < lines[j][2:]
+		}
+		return lines[i] < lines[j]
+	})
+	return strings.Join(lines, "\n")
+}
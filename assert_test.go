@@ -0,0 +1,102 @@
+package postgrestest
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertEmptySchema(t *testing.T) {
+	t.Parallel()
+	testDB := NewPostgresTest(t)
+	db, err := sql.Open("pgx", testDB)
+	require.NoError(t, err)
+	defer db.Close()
+	AssertEmptySchema(t, db)
+	_, err = db.Exec(`CREATE TABLE items (id serial PRIMARY KEY);`)
+	require.NoError(t, err)
+	ft := &fakeT{}
+	ft.run(func() {
+		AssertEmptySchema(ft, db)
+	})
+	require.Contains(t, ft.failures(), "table items")
+	require.Contains(t, ft.failures(), "sequence items_id_seq")
+}
+
+func TestRowAssertions(t *testing.T) {
+	t.Parallel()
+	db := NewPostgresTestDB(t)
+	_, err := db.Exec(`CREATE SCHEMA billing;
+CREATE TABLE billing.users (id int PRIMARY KEY, email text NOT NULL, deleted_at timestamptz);
+INSERT INTO billing.users (id, email, deleted_at) VALUES (1, 'alice@example.com', NULL), (2, 'bob@example.com', now());`)
+	require.NoError(t, err)
+	AssertRowCount(t, db, "billing.users", 2)
+	AssertRowExists(t, db, "billing.users", map[string]interface{}{"email": "alice@example.com", "deleted_at": nil})
+	AssertRowExists(t, db, "billing.users", map[string]interface{}{"id": 2})
+	AssertRowExists(t, db, "billing.users", nil)
+	require.Equal(t, 2, RequireQueryInt(t, db, `SELECT max(id) FROM billing.users;`))
+	require.Equal(t, "bob@example.com", RequireQueryString(t, db, `SELECT email FROM billing.users WHERE id = $1;`, 2))
+	ft := &fakeT{}
+	ft.run(func() {
+		AssertRowCount(ft, db, "billing.users", 3)
+	})
+	require.Contains(t, ft.failures(), "unexpected number of rows on billing.users")
+	ft = &fakeT{}
+	ft.run(func() {
+		AssertRowExists(ft, db, "billing.users", map[string]interface{}{"email": "bob@example.com", "deleted_at": nil})
+	})
+	require.Contains(t, ft.failures(), "no row on billing.users with the values map[deleted_at:<nil> email:bob@example.com]")
+	ft = &fakeT{}
+	ft.run(func() {
+		RequireQueryString(ft, db, `SELECT email FROM billing.users WHERE id = $1;`, 3)
+	})
+	require.Contains(t, ft.failures(), "sql: no rows in result set")
+}
+
+func TestAssertTableEquals(t *testing.T) {
+	t.Parallel()
+	db := NewPostgresTestDB(t)
+	_, err := db.Exec(`CREATE TABLE orders (id bigint PRIMARY KEY, status text NOT NULL, total numeric(10, 2) NOT NULL, cancelled_at timestamptz, empty text);
+INSERT INTO orders (id, status, total) VALUES (1, 'paid', 1.5), (2, 'pending', 10);`)
+	require.NoError(t, err)
+	AssertTableEquals(t, db, "orders", []map[string]interface{}{
+		{"id": 2, "status": "pending", "total": "10.00", "cancelled_at": nil},
+		{"id": 1, "status": "paid", "total": "1.50", "cancelled_at": nil},
+	})
+	// a subset of the columns
+	AssertTableEquals(t, db, "orders", []map[string]interface{}{{"status": "paid"}, {"status": "pending"}})
+	ft := &fakeT{}
+	ft.run(func() {
+		AssertTableEquals(ft, db, "orders", []map[string]interface{}{{"id": 1, "status": "paid"}, {"id": 2, "status": "shipped"}})
+	})
+	require.Contains(t, ft.failures(), "the rows of orders aren't the expected ones (-want +got):\n"+
+		"+ {id: \"2\", status: \"pending\"}\n"+
+		"- {id: \"2\", status: \"shipped\"}")
+	ft = &fakeT{}
+	ft.run(func() {
+		AssertTableEquals(ft, db, "orders", nil)
+	})
+	require.Contains(t, ft.failures(), "unexpected number of rows on orders")
+}
+
+func TestRowsDiff(t *testing.T) {
+	t.Parallel()
+	require.Empty(t, rowsDiff([]string{"{id: 1}", "{id: 2}", "{id: 2}"}, []string{"{id: 2}", "{id: 1}", "{id: 2}"}))
+	require.Equal(t, "- {id: 1}\n+ {id: 2}\n+ {id: 3}", rowsDiff([]string{"{id: 1}", "{id: 2}"}, []string{"{id: 2}", "{id: 2}", "{id: 3}"}))
+	require.Equal(t, `{id: "1", name: NULL, at: "2023-06-01T10:00:00.5Z"}`, formatRow(
+		[]string{"id", "name", "at"},
+		[]interface{}{int64(1), nil, time.Date(2023, 6, 1, 12, 0, 0, 500000000, time.FixedZone("CEST", 2*60*60))},
+	))
+	require.NotEqual(t, formatValue(nil), formatValue("NULL"))
+}
+
+func TestAssertTableEqualsMismatchedColumns(t *testing.T) {
+	t.Parallel()
+	ft := &fakeT{}
+	ft.run(func() {
+		AssertTableEquals(ft, nil, "orders", []map[string]interface{}{{"id": 1}, {"status": "paid"}})
+	})
+	require.Contains(t, ft.failures(), "the expected row 1 doesn't have the columns id of the first one")
+}
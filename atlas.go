@@ -0,0 +1,106 @@
+package postgrestest
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// WithAtlasSchema is an option that applies the declarative Atlas schema file, in
+// the HCL or SQL format, to the test database after creating it, before running the
+// migrations and seeds. It allows teams managing the schema as code to get a fully
+// provisioned test database in one call.
+// The schema is applied with the atlas command, which must be on the PATH, using a
+// temporary database on the base server as the Atlas dev database. Atlas connects
+// with sslmode=disable unless the base address provides a sslmode.
+func WithAtlasSchema(path string) Option {
+	return func(opts *options) {
+		opts.atlasSchema = path
+	}
+}
+
+// applyAtlasSchema applies the Atlas schema to the test database with atlas schema apply.
+func applyAtlasSchema(ctx context.Context, t TestingT, opts *options, db *sql.DB, databaseName string) error {
+	schemaPath, err := filepath.Abs(opts.atlasSchema)
+	if err != nil {
+		return fmt.Errorf("failed to apply the Atlas schema: %w", err)
+	}
+	if _, err := os.Stat(schemaPath); err != nil {
+		return fmt.Errorf("failed to apply the Atlas schema: %w", err)
+	}
+	atlas, err := exec.LookPath("atlas")
+	if err != nil {
+		return fmt.Errorf("failed to apply the Atlas schema, atlas must be installed: %w", err)
+	}
+	// atlas needs an empty database to normalize the schema, it has the database prefix
+	// and the creation time so CleanupStaleDatabases finds it when it's leaked
+	devName, err := randomName(t, opts, opts.databasePrefix+"dev_")
+	if err != nil {
+		return err
+	}
+	if _, err := db.ExecContext(ctx, `CREATE DATABASE `+quoteIdentifier(devName)+`;`); err != nil {
+		return fmt.Errorf("failed to create the Atlas dev database: %w", err)
+	}
+	defer func() {
+		if err := DefaultDeleteDatabaseFunction(db, devName); err != nil {
+			logf(t, "postgrestest: failed to drop the Atlas dev database %s: %v", devName, err)
+		}
+	}()
+	comment := createdAtComment(opts.now())
+	if _, err := db.ExecContext(ctx, `COMMENT ON DATABASE `+quoteIdentifier(devName)+` IS `+quoteLiteral(comment)); err != nil {
+		return fmt.Errorf("failed to create the Atlas dev database: %w", err)
+	}
+	dsn, password, err := atlasURL(opts, databaseName)
+	if err != nil {
+		return err
+	}
+	devDSN, _, err := atlasURL(opts, devName)
+	if err != nil {
+		return err
+	}
+	// the password is passed on the environment, the arguments are visible to other users
+	env := os.Environ()
+	if password != "" {
+		env = append(env, "PGPASSWORD="+password)
+	}
+	cmd := exec.CommandContext(ctx, atlas, "schema", "apply", "--auto-approve",
+		"--url", dsn, "--dev-url", devDSN, "--to", "file://"+filepath.ToSlash(schemaPath))
+	cmd.Env = env
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to apply the Atlas schema: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// atlasURL returns the URL atlas connects to the database with, without the password,
+// and the password.
+func atlasURL(opts *options, databaseName string) (string, string, error) {
+	dsn, err := opts.setupDSN(databaseName)
+	if err != nil {
+		return "", "", err
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", err
+	}
+	var password string
+	if u.User != nil {
+		password, _ = u.User.Password()
+		u.User = url.User(u.User.Username())
+	}
+	// atlas uses lib/pq, which requires SSL by default
+	q := u.Query()
+	if !q.Has("sslmode") {
+		q.Set("sslmode", "disable")
+		u.RawQuery = q.Encode()
+	}
+	return u.String(), password, nil
+}
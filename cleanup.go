@@ -0,0 +1,93 @@
+package postgrestest
+
+import (
+	"errors"
+	"sync"
+)
+
+// WithAsyncCleanup is an option that deletes the test database in the background
+// once the test finishes, instead of making its cleanup wait for DROP DATABASE.
+// The deletes are queued and run one at a time, they must be waited for before the
+// process exits, with RunMain, which does it after the tests, or with FlushCleanups
+// on TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		code := m.Run()
+//		if err := postgrestest.FlushCleanups(); err != nil {
+//			log.Print(err)
+//		}
+//		os.Exit(code)
+//	}
+//
+// The deletes still queued when the process exits are lost, leaving their databases
+// behind. The errors deleting the databases are passed to the cleanup error handler,
+// or returned by FlushCleanups, since the test that created them already finished.
+func WithAsyncCleanup() Option {
+	return func(opts *options) {
+		opts.asyncCleanup = true
+	}
+}
+
+// FlushCleanups waits for the deletes queued by the tests using WithAsyncCleanup,
+// returning the errors of the ones that failed since the last call.
+func FlushCleanups() error {
+	return asyncCleanups.flush()
+}
+
+// asyncCleanups is the queue of the deletes of the tests using WithAsyncCleanup.
+var asyncCleanups = newCleanupQueue()
+
+// cleanupQueue runs the queued deletes in the background, one at a time.
+type cleanupQueue struct {
+	mu   sync.Mutex
+	idle *sync.Cond
+	// pending is the number of deletes queued or running.
+	pending int
+	errs    []error
+	worker  sync.Mutex
+}
+
+// newCleanupQueue returns an empty cleanup queue.
+func newCleanupQueue() *cleanupQueue {
+	q := &cleanupQueue{}
+	q.idle = sync.NewCond(&q.mu)
+	return q
+}
+
+// queue runs the delete in the background, the errors are passed to the handler when
+// set, or kept for flush.
+func (q *cleanupQueue) queue(deleteDatabase func() error, handler func(error)) {
+	q.mu.Lock()
+	q.pending++
+	q.mu.Unlock()
+	go func() {
+		q.worker.Lock()
+		err := deleteDatabase()
+		q.worker.Unlock()
+		if err != nil && handler != nil {
+			handler(err)
+			err = nil
+		}
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if err != nil {
+			q.errs = append(q.errs, err)
+		}
+		q.pending--
+		if q.pending == 0 {
+			q.idle.Broadcast()
+		}
+	}()
+}
+
+// flush waits for the queued deletes and returns the errors of the failed ones.
+func (q *cleanupQueue) flush() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.pending > 0 {
+		q.idle.Wait()
+	}
+	errs := q.errs
+	q.errs = nil
+	return errors.Join(errs...)
+}
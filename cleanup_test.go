@@ -0,0 +1,72 @@
+package postgrestest
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithAsyncCleanup(t *testing.T) {
+	t.Parallel()
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var deleted []string
+	var handled []error
+	opts := []Option{
+		WithAsyncCleanup(),
+		WithCreateDatabaseFunction(func(db *sql.DB, database string) error {
+			return nil
+		}),
+		WithDeleteDatabaseFunction(func(db *sql.DB, database string) error {
+			<-release
+			mu.Lock()
+			defer mu.Unlock()
+			deleted = append(deleted, database)
+			if len(deleted) == 1 {
+				return errors.New("database is being accessed by other users")
+			}
+			return nil
+		}),
+		// the errors of the other tests queued on the shared queue aren't returned here
+		WithCleanupErrorHandler(func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			handled = append(handled, err)
+		}),
+	}
+	// sequential tests don't wait for the deletes either
+	for i := 0; i < 2; i++ {
+		ft := &fakeT{}
+		ft.run(func() {
+			NewPostgresTest(ft, opts...)
+		})
+		ft.runCleanups()
+		require.Empty(t, ft.failures())
+	}
+	mu.Lock()
+	require.Empty(t, deleted)
+	mu.Unlock()
+	close(release)
+	require.NoError(t, FlushCleanups())
+	require.Len(t, deleted, 2)
+	require.Len(t, handled, 1)
+	require.ErrorContains(t, handled[0], "database is being accessed by other users")
+}
+
+func TestCleanupQueue(t *testing.T) {
+	t.Parallel()
+	q := newCleanupQueue()
+	var handled []error
+	handler := func(err error) {
+		handled = append(handled, err)
+	}
+	q.queue(func() error { return errors.New("first") }, handler)
+	q.queue(func() error { return nil }, handler)
+	q.queue(func() error { return errors.New("third") }, nil)
+	require.EqualError(t, q.flush(), "third")
+	require.Len(t, handled, 1)
+	require.NoError(t, q.flush())
+}
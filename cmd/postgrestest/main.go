@@ -0,0 +1,107 @@
+// Command postgrestest manages the test databases left behind on the base server,
+// by test binaries that crashed or were killed before their cleanup ran.
+//
+// Usage:
+//
+//	postgrestest list [-address dsn] [-prefix testing_db_]
+//	postgrestest clean [-address dsn] [-prefix testing_db_] [-older-than 24h] [-dry-run]
+//
+// The base server is the one of the address flag, or the default one of the
+// package, configured with the TESTING_POSTGRES_* environment variables.
+// The clean subcommand only drops the databases with the creation time recorded on
+// their comment, see postgrestest.CleanupStaleDatabases.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/crossworth/postgrestest"
+)
+
+const usage = `usage:
+  postgrestest list [-address dsn] [-prefix testing_db_]
+  postgrestest clean [-address dsn] [-prefix testing_db_] [-older-than 24h] [-dry-run]
+`
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "postgrestest:", err)
+		os.Exit(1)
+	}
+}
+
+// run runs the subcommand of the arguments, writing its output to stdout.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return errors.New("missing subcommand")
+	}
+	flags := flag.NewFlagSet("postgrestest "+args[0], flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	address := flags.String("address", "", "the base server `dsn`, the default one of the package when empty")
+	prefix := flags.String("prefix", "testing_db_", "the `prefix` of the test database names")
+	switch args[0] {
+	case "list":
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		return list(ctx, stdout, *address, postgrestest.WithDatabasePrefix(*prefix))
+	case "clean":
+		olderThan := flags.Duration("older-than", 24*time.Hour, "drop the databases created more than `duration` ago")
+		dryRun := flags.Bool("dry-run", false, "print the databases that would be dropped without dropping them")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		return clean(ctx, stdout, *address, *olderThan, *dryRun, postgrestest.WithDatabasePrefix(*prefix))
+	default:
+		fmt.Fprint(stderr, usage)
+		return fmt.Errorf("unknown subcommand %q", args[0])
+	}
+}
+
+// list prints the test databases with their creation time.
+func list(ctx context.Context, stdout io.Writer, address string, opts ...postgrestest.Option) error {
+	databases, err := postgrestest.ListTestDatabases(ctx, address, opts...)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCREATED\tTEMPLATE")
+	for _, database := range databases {
+		created := "unknown"
+		if !database.CreatedAt.IsZero() {
+			created = database.CreatedAt.Local().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%t\n", database.Name, created, database.IsTemplate)
+	}
+	return w.Flush()
+}
+
+// clean drops the test databases created more than olderThan ago, printing their names.
+func clean(ctx context.Context, stdout io.Writer, address string, olderThan time.Duration, dryRun bool, opts ...postgrestest.Option) error {
+	if dryRun {
+		databases, err := postgrestest.ListTestDatabases(ctx, address, opts...)
+		if err != nil {
+			return err
+		}
+		threshold := time.Now().Add(-olderThan)
+		for _, database := range databases {
+			if !database.CreatedAt.IsZero() && database.CreatedAt.Before(threshold) {
+				fmt.Fprintln(stdout, "would drop", database.Name)
+			}
+		}
+		return nil
+	}
+	dropped, err := postgrestest.CleanupStaleDatabases(ctx, address, olderThan, opts...)
+	for _, name := range dropped {
+		fmt.Fprintln(stdout, "dropped", name)
+	}
+	return err
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunUsage(t *testing.T) {
+	t.Parallel()
+	var stdout, stderr bytes.Buffer
+	require.EqualError(t, run(context.Background(), nil, &stdout, &stderr), "missing subcommand")
+	require.Contains(t, stderr.String(), "usage:")
+	stderr.Reset()
+	require.EqualError(t, run(context.Background(), []string{"drop"}, &stdout, &stderr), `unknown subcommand "drop"`)
+	require.Contains(t, stderr.String(), "postgrestest clean")
+	require.Error(t, run(context.Background(), []string{"list", "-bogus"}, &stdout, &stderr))
+	require.Empty(t, stdout.String())
+}
+
+func TestRunInvalidPrefix(t *testing.T) {
+	t.Parallel()
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), []string{"clean", "-prefix", "Testing-"}, &stdout, &stderr)
+	require.EqualError(t, err, `invalid database prefix "Testing-", it must be a lowercase identifier`)
+}
+
+func TestRunUnreachable(t *testing.T) {
+	t.Parallel()
+	// a port nothing listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := "postgres://postgres:root@" + listener.Addr().String()
+	require.NoError(t, listener.Close())
+	var stdout, stderr bytes.Buffer
+	require.Error(t, run(context.Background(), []string{"list", "-address", address}, &stdout, &stderr))
+}
@@ -0,0 +1,55 @@
+package postgrestest
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeedCSV(t *testing.T) {
+	t.Parallel()
+	for _, driver := range []string{"pgx", "pgx/v5"} {
+		driver := driver
+		t.Run(driver, func(t *testing.T) {
+			t.Parallel()
+			db := NewPostgresTestDB(t, WithDriverName(driver))
+			_, err := db.Exec(`CREATE TABLE products (id serial PRIMARY KEY, name text NOT NULL, price numeric);`)
+			require.NoError(t, err)
+			SeedCSV(t, db, "products", strings.NewReader("name,price\nApple,1.50\n\"Banana, ripe\",0.25\nEmpty,\n"))
+			var name string
+			require.NoError(t, db.QueryRow(`SELECT name FROM products WHERE id = 2;`).Scan(&name))
+			require.Equal(t, "Banana, ripe", name)
+			var price sql.NullString
+			require.NoError(t, db.QueryRow(`SELECT price FROM products WHERE name = 'Empty';`).Scan(&price))
+			require.False(t, price.Valid)
+		})
+	}
+}
+
+func TestWithCSVSeed(t *testing.T) {
+	t.Parallel()
+	db := NewPostgresTestDB(t,
+		WithSetupFunction(func(db *sql.DB) error {
+			_, err := db.Exec(`CREATE TABLE products (id serial PRIMARY KEY, name text NOT NULL, price numeric);`)
+			return err
+		}),
+		WithCSVSeed("products", "testdata/products.csv"),
+	)
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM products;`).Scan(&count))
+	require.Equal(t, 3, count)
+}
+
+func TestCopyCSVErrors(t *testing.T) {
+	t.Parallel()
+	db, err := sql.Open("sqlite", "file:copy_csv?mode=memory&cache=shared")
+	require.NoError(t, err)
+	defer db.Close()
+	err = copyCSV(context.Background(), db, "products", strings.NewReader(""))
+	require.EqualError(t, err, "failed to seed products: the CSV has no header")
+	err = copyCSV(context.Background(), db, "products", strings.NewReader("name,price\nApple,1.50\n"))
+	require.ErrorIs(t, err, errCopyNotSupported)
+}
@@ -0,0 +1,100 @@
+package postgrestest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// errNotConnected is reported for the checks skipped because the connection failed.
+var errNotConnected = errors.New("skipped, not connected to the base server")
+
+// DiagnosisReport summarizes the state of the base server, each check
+// reports its own error instead of aborting the diagnosis.
+type DiagnosisReport struct {
+	// ConnectError is why connecting to the base server failed, if it did.
+	ConnectError error
+	// ServerVersion is the server_version_num of the server, like 150004.
+	ServerVersion      int
+	ServerVersionError error
+	// CanCreateDatabase reports whether the role has the CREATEDB privilege.
+	CanCreateDatabase   bool
+	CreateDatabaseError error
+	// TestDatabases is the number of testing_db_ databases on the server,
+	// a large number usually means test databases are being leaked.
+	TestDatabases      int
+	TestDatabasesError error
+}
+
+// Connected reports whether connecting to the base server succeeded.
+func (r DiagnosisReport) Connected() bool {
+	return r.ConnectError == nil
+}
+
+// String returns a human readable summary of the report, like for a banner
+// printed when the test suite starts.
+func (r DiagnosisReport) String() string {
+	var b strings.Builder
+	b.WriteString("postgrestest diagnosis:\n")
+	if r.ConnectError != nil {
+		fmt.Fprintf(&b, "  connection: failed: %v\n", r.ConnectError)
+	} else {
+		b.WriteString("  connection: ok\n")
+	}
+	if r.ServerVersionError != nil {
+		fmt.Fprintf(&b, "  server version: unknown: %v\n", r.ServerVersionError)
+	} else {
+		fmt.Fprintf(&b, "  server version: %d\n", r.ServerVersion)
+	}
+	if r.CreateDatabaseError != nil {
+		fmt.Fprintf(&b, "  create database: failed: %v\n", r.CreateDatabaseError)
+	} else {
+		b.WriteString("  create database: ok\n")
+	}
+	if r.TestDatabasesError != nil {
+		fmt.Fprintf(&b, "  test databases: unknown: %v\n", r.TestDatabasesError)
+	} else {
+		fmt.Fprintf(&b, "  test databases: %d\n", r.TestDatabases)
+	}
+	return b.String()
+}
+
+// Diagnose checks the base server without failing anything, it's meant to be
+// run when the test suite starts to catch misconfigurations early.
+// An empty base address uses the same default as NewPostgresTest. The test databases
+// are counted with the database prefix, testing_db_ unless changed with WithDatabasePrefix.
+func Diagnose(baseAddress string, opts ...Option) DiagnosisReport {
+	if baseAddress != "" {
+		opts = append(append([]Option{}, opts...), WithFixedBaseAddress(baseAddress))
+	}
+	report := DiagnosisReport{
+		ServerVersionError:  errNotConnected,
+		CreateDatabaseError: errNotConnected,
+		TestDatabasesError:  errNotConnected,
+	}
+	o, err := newOptions(opts)
+	if err != nil {
+		report.ConnectError = err
+		return report
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	db, err := o.connect(o.maintenanceAddress)
+	if err != nil {
+		report.ConnectError = err
+		return report
+	}
+	defer db.Close()
+	if err := db.PingContext(ctx); err != nil {
+		report.ConnectError = diagnoseConnectionFailure(o, err)
+		return report
+	}
+	report.ServerVersionError = db.QueryRowContext(ctx, `SHOW server_version_num`).Scan(&report.ServerVersion)
+	report.CreateDatabaseError = checkCreateDatabasePrivilege(ctx, db)
+	report.CanCreateDatabase = report.CreateDatabaseError == nil
+	report.TestDatabasesError = db.QueryRowContext(ctx,
+		`SELECT count(*) FROM pg_database WHERE datname LIKE $1;`, o.databasePrefixPattern()).Scan(&report.TestDatabases)
+	return report
+}
@@ -0,0 +1,265 @@
+package postgrestest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultDockerImage is the image of the containers started by WithDockerProvisioner.
+const defaultDockerImage = "postgres:14"
+
+// dockerReadyTimeout is how long we wait for a provisioned server to accept connections.
+const dockerReadyTimeout = time.Minute
+
+// WithDockerProvisioner is an option that starts a disposable Postgres Docker container,
+// and uses it as the base server, when the base server isn't reachable. It allows
+// running the tests on CI and on machines without a running Postgres server, as long
+// as the docker command is on the PATH.
+// The container is started the first time a test database is created, and shared by
+// the tests of the test binary. It's removed when the test binary exits, even when it
+// crashes, by a background shell process waiting for it. WithDockerReuse keeps it
+// running for the next runs instead.
+func WithDockerProvisioner() Option {
+	return func(opts *options) {
+		opts.dockerProvisioner = true
+	}
+}
+
+// WithDockerReuse is an option that keeps the container of WithDockerProvisioner
+// running after the test binary exits, shared by the test binaries running at the same
+// time and by the next runs, so only the first run pays for starting it. The container
+// is named postgrestest-provisioned, or like postgrestest-provisioned-16.3 with
+// WithPostgresVersion. Since it's reused as is, it must be removed to pick up changes
+// to its image or to the settings the package starts it with:
+//
+//	docker rm -f postgrestest-provisioned
+func WithDockerReuse() Option {
+	return func(opts *options) {
+		opts.dockerReuse = true
+	}
+}
+
+// WithPostgresVersion is an option that pins the version of the server provisioned by
+// WithDockerProvisioner, a major version like "16" or an exact one like "16.3", so the
+// tests run on the version production runs instead of whatever image is cached. The
+// container runs the official postgres image with the version as the tag.
+// With a version the provisioned server is always used, even when the base server is
+// reachable, since its version is unknown. Each version has its own container.
+// The servers of postgrestesttc and postgrestestembedded are pinned with their own
+// WithTestcontainersVersion and WithEmbeddedPostgresVersion.
+func WithPostgresVersion(version string) Option {
+	return func(opts *options) {
+		opts.postgresVersion = version
+	}
+}
+
+// validPostgresVersion matches the versions accepted by WithPostgresVersion.
+var validPostgresVersion = regexp.MustCompile(`^[0-9]+(\.[0-9]+){0,2}$`)
+
+// ValidatePostgresVersion returns an error when the version isn't a major or exact
+// Postgres version, like 16 or 16.3, the versions accepted by WithPostgresVersion.
+// It allows the packages providing base servers to accept the same versions.
+func ValidatePostgresVersion(version string) error {
+	if !validPostgresVersion.MatchString(version) {
+		return fmt.Errorf("invalid Postgres version %q, it must be like 16 or 16.3", version)
+	}
+	return nil
+}
+
+// dockerServer returns the name and image of the container provisioned for the version,
+// the default image when it's empty. The containers that aren't reused are named after
+// the process, so each test binary starts its own.
+func dockerServer(version string, reuse bool) (name, image string) {
+	name, image = "postgrestest-provisioned", defaultDockerImage
+	if version != "" {
+		name, image = name+"-"+version, "postgres:"+version
+	}
+	if !reuse {
+		name = fmt.Sprintf("%s-%d", name, os.Getpid())
+	}
+	return name, image
+}
+
+// provisionedServers holds the base addresses of the containers started by the
+// process, keyed by the container name, so they are only provisioned once. Failures
+// aren't remembered, the next test tries again.
+var provisionedServers = struct {
+	sync.Mutex
+	servers map[string]*provisionedServer
+}{servers: make(map[string]*provisionedServer)}
+
+// provisionedServer is a container provisioned by the process.
+type provisionedServer struct {
+	mu      sync.Mutex
+	address string
+}
+
+// provision replaces the base address with the address of a provisioned container,
+// when WithDockerProvisioner is used and the base server is unreachable. It runs
+// when the test database is created, so only the tests pay for it.
+func (o *options) provision() error {
+	if !o.dockerProvisioner || o.provisioned {
+		return nil
+	}
+	if o.postgresVersion == "" && baseAddressReachable(o.baseAddress) {
+		o.provisioned = true
+		return nil
+	}
+	address, err := provisionDockerServer(o.postgresVersion, o.dockerReuse)
+	if err != nil {
+		return err
+	}
+	o.provisioned = true
+	return o.setBaseAddress(address)
+}
+
+// provisionDockerServer returns the address of the container of the version, starting
+// it the first time it's used by the process.
+func provisionDockerServer(version string, reuse bool) (string, error) {
+	name, image := dockerServer(version, reuse)
+	provisionedServers.Lock()
+	server, ok := provisionedServers.servers[name]
+	if !ok {
+		server = &provisionedServer{}
+		provisionedServers.servers[name] = server
+	}
+	provisionedServers.Unlock()
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.address == "" {
+		address, err := startDockerServer(name, image, !reuse)
+		if err != nil {
+			return "", err
+		}
+		server.address = address
+	}
+	return server.address, nil
+}
+
+// baseAddressReachable reports if the server of the base address accepts TCP connections.
+func baseAddressReachable(address string) bool {
+	u, err := parseBaseAddress(address)
+	if err != nil {
+		return false
+	}
+	port := u.Port()
+	if port == "" {
+		port = "5432"
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(u.Hostname(), port), time.Second)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// startDockerServer starts the named container, or reuses it when it's already running,
+// and returns its base address once it accepts connections. When removeOnExit is set,
+// the container it starts is removed when the process exits.
+func startDockerServer(name, image string, removeOnExit bool) (string, error) {
+	docker, err := exec.LookPath("docker")
+	if err != nil {
+		return "", fmt.Errorf("failed to provision the base server, docker must be installed: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dockerReadyTimeout)
+	defer cancel()
+	hostPort, err := dockerPort(ctx, docker, name)
+	if err != nil {
+		// we disable a few options to make the database faster for testing, like docker-compose.yml
+		_, runErr := runDocker(ctx, docker, "run", "--detach", "--rm", "--name", name,
+			"--label", "postgrestest", "--env", "POSTGRES_PASSWORD=root", "--publish", "127.0.0.1::5432",
+			image, "postgres", "-c", "fsync=off", "-c", "synchronous_commit=off",
+			"-c", "full_page_writes=off", "-c", "max_connections=500",
+			"-c", "shared_preload_libraries=pg_stat_statements")
+		if runErr == nil && removeOnExit {
+			if err := startDockerReaper(docker, os.Getpid(), name); err != nil {
+				_ = stopDockerServer(name)
+				return "", fmt.Errorf("failed to provision the base server, the container can't be removed on exit, WithDockerReuse keeps it instead: %w", err)
+			}
+		}
+		// another test binary may have started it meanwhile
+		hostPort, err = dockerPort(ctx, docker, name)
+		if err != nil {
+			if runErr != nil {
+				err = runErr
+			}
+			return "", fmt.Errorf("failed to provision the base server: %w", err)
+		}
+	}
+	address := "postgres://postgres:root@" + hostPort
+	if err := waitForServer(ctx, address); err != nil {
+		return "", fmt.Errorf("failed to provision the base server, the container %s isn't ready: %w", name, err)
+	}
+	return address, nil
+}
+
+// startDockerReaper starts a background shell process that removes the container once
+// the process with the pid exits, however it exits. It ignores the signals sent to the
+// whole process group, like on Ctrl+C, and its output is discarded, so go test doesn't
+// wait for it.
+func startDockerReaper(docker string, pid int, name string) error {
+	cmd := exec.Command("sh", "-c", `trap '' HUP INT QUIT TERM
+while kill -0 "$1" 2>/dev/null; do sleep 1; done
+exec "$0" rm --force "$2"`, docker, strconv.Itoa(pid), name)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// stopDockerServer removes the named container, it's a no-op when it doesn't exist.
+func stopDockerServer(name string) error {
+	docker, err := exec.LookPath("docker")
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dockerReadyTimeout)
+	defer cancel()
+	_, err = runDocker(ctx, docker, "rm", "--force", name)
+	return err
+}
+
+// dockerPort returns the host address the Postgres port of the container is published on.
+func dockerPort(ctx context.Context, docker, name string) (string, error) {
+	output, err := runDocker(ctx, docker, "port", name, "5432/tcp")
+	if err != nil {
+		return "", err
+	}
+	// the port may be published on IPv4 and IPv6, one per line
+	hostPort, _, _ := strings.Cut(output, "\n")
+	return hostPort, nil
+}
+
+// runDocker runs the docker command and returns its trimmed output.
+func runDocker(ctx context.Context, docker string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, docker, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// waitForServer pings the server until it accepts connections or the context is done.
+// The official image runs the initialization scripts on a server only listening on
+// the Unix socket, so the first successful ping is on the final server.
+func waitForServer(ctx context.Context, address string) error {
+	db, err := DefaultConnectFunction(address)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return pingUntilReady(ctx, db, 250*time.Millisecond)
+}
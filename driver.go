@@ -0,0 +1,221 @@
+package postgrestest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"time"
+)
+
+// errNamedArgsNotSupported is returned when named arguments are used with drivers that don't support them.
+var errNamedArgsNotSupported = errors.New("driver does not support the use of named parameters")
+
+// statementHook is called after a statement is executed with its duration and error.
+type statementHook func(query string, duration time.Duration, err error)
+
+// openHooked opens a connection using the registered driver that calls the hook
+// for every statement executed on it, measured with the clock, time.Now when nil.
+func openHooked(driverName string, dsn string, now func() time.Time, hook statementHook) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	d := db.Driver()
+	_ = db.Close()
+	return sql.OpenDB(&hookedConnector{driver: d, dsn: dsn, now: now, hook: hook}), nil
+}
+
+// hookedConnector is a driver.Connector returning connections that call the hook.
+type hookedConnector struct {
+	driver driver.Driver
+	dsn    string
+	now    func() time.Time
+	hook   statementHook
+}
+
+func (c *hookedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	var conn driver.Conn
+	var err error
+	if driverContext, ok := c.driver.(driver.DriverContext); ok {
+		var connector driver.Connector
+		connector, err = driverContext.OpenConnector(c.dsn)
+		if err != nil {
+			return nil, err
+		}
+		conn, err = connector.Connect(ctx)
+	} else {
+		conn, err = c.driver.Open(c.dsn)
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := c.now
+	if now == nil {
+		now = time.Now
+	}
+	return &hookedConn{Conn: conn, now: now, hook: c.hook}, nil
+}
+
+func (c *hookedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// hookedConn wraps a driver.Conn calling the hook for the executed statements.
+// The optional interfaces are forwarded to the wrapped connection, database/sql
+// falls back to the prepared statements path when it doesn't implement them.
+type hookedConn struct {
+	driver.Conn
+	now  func() time.Time
+	hook statementHook
+}
+
+func (c *hookedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *hookedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &hookedStmt{Stmt: stmt, query: query, now: c.now, hook: c.hook}, nil
+}
+
+func (c *hookedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin() //nolint:staticcheck
+}
+
+func (c *hookedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := c.now()
+	result, err := execer.ExecContext(ctx, query, args)
+	if err != driver.ErrSkip { //nolint:errorlint
+		c.hook(query, c.now().Sub(start), err)
+	}
+	return result, err
+}
+
+func (c *hookedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := c.now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != driver.ErrSkip { //nolint:errorlint
+		c.hook(query, c.now().Sub(start), err)
+	}
+	return rows, err
+}
+
+func (c *hookedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *hookedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+func (c *hookedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *hookedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// hookedStmt wraps a driver.Stmt calling the hook when it's executed.
+type hookedStmt struct {
+	driver.Stmt
+	query string
+	now   func() time.Time
+	hook  statementHook
+}
+
+func (s *hookedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	start := s.now()
+	result, err := s.Stmt.Exec(args) //nolint:staticcheck
+	s.hook(s.query, s.now().Sub(start), err)
+	return result, err
+}
+
+func (s *hookedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Exec(values)
+	}
+	start := s.now()
+	result, err := execer.ExecContext(ctx, args)
+	s.hook(s.query, s.now().Sub(start), err)
+	return result, err
+}
+
+func (s *hookedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	start := s.now()
+	rows, err := s.Stmt.Query(args) //nolint:staticcheck
+	s.hook(s.query, s.now().Sub(start), err)
+	return rows, err
+}
+
+func (s *hookedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Query(values)
+	}
+	start := s.now()
+	rows, err := queryer.QueryContext(ctx, args)
+	s.hook(s.query, s.now().Sub(start), err)
+	return rows, err
+}
+
+func (s *hookedStmt) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// namedValuesToValues converts the arguments for drivers without context support.
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errNamedArgsNotSupported
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
@@ -0,0 +1,109 @@
+package postgrestest
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/stretchr/testify/require"
+)
+
+// AssertUsesIndex fails the test, with the full plan, if the plan of the query doesn't
+// scan the index, with an index, index only or bitmap index scan. It locks in the query
+// plans the performance depends on. The query is explained with EXPLAIN (FORMAT JSON),
+// without running it, with the arguments bound to its parameters.
+// The planner prefers sequential scans on small tables, the test data must be large
+// enough, and analyzed, for the plan to match the production one.
+func AssertUsesIndex(t TestingT, db *sql.DB, query string, indexName string, args ...interface{}) {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	plan, formatted, err := explain(db, query, args)
+	require.NoError(t, err, "failed to explain the query")
+	if !plan.usesIndex(indexName) {
+		require.Fail(t, fmt.Sprintf("the query doesn't use the index %s", indexName), "query: %s\nplan: %s", query, formatted)
+	}
+}
+
+// AssertNoSeqScan fails the test, with the full plan, if the plan of the query has
+// a sequential scan on any table. It's explained like with AssertUsesIndex.
+func AssertNoSeqScan(t TestingT, db *sql.DB, query string, args ...interface{}) {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	plan, formatted, err := explain(db, query, args)
+	require.NoError(t, err, "failed to explain the query")
+	if relations := plan.seqScans(); len(relations) > 0 {
+		require.Fail(t, fmt.Sprintf("the query has a sequential scan on %v", relations), "query: %s\nplan: %s", query, formatted)
+	}
+}
+
+// planNode is a node of a plan of EXPLAIN (FORMAT JSON).
+type planNode struct {
+	NodeType     string     `json:"Node Type"`
+	RelationName string     `json:"Relation Name"`
+	IndexName    string     `json:"Index Name"`
+	Plans        []planNode `json:"Plans"`
+}
+
+// explain returns the plan of the query and its indented JSON.
+func explain(db *sql.DB, query string, args []interface{}) (planNode, string, error) {
+	var output []byte
+	if err := db.QueryRow(`EXPLAIN (FORMAT JSON) `+query, args...).Scan(&output); err != nil {
+		return planNode{}, "", err
+	}
+	return parsePlan(output)
+}
+
+// parsePlan returns the plan of the output of EXPLAIN (FORMAT JSON) and its indented JSON.
+func parsePlan(output []byte) (planNode, string, error) {
+	var plans []struct {
+		Plan planNode `json:"Plan"`
+	}
+	if err := json.Unmarshal(output, &plans); err != nil {
+		return planNode{}, "", fmt.Errorf("failed to parse the plan: %w", err)
+	}
+	if len(plans) != 1 {
+		return planNode{}, "", fmt.Errorf("failed to parse the plan: expected a single plan, got %d", len(plans))
+	}
+	var formatted bytes.Buffer
+	if err := json.Indent(&formatted, output, "", "  "); err != nil {
+		return planNode{}, "", fmt.Errorf("failed to parse the plan: %w", err)
+	}
+	return plans[0].Plan, formatted.String(), nil
+}
+
+// walk calls fn for the node and all of its children.
+func (n planNode) walk(fn func(node planNode)) {
+	fn(n)
+	for _, child := range n.Plans {
+		child.walk(fn)
+	}
+}
+
+// usesIndex returns whether any node of the plan scans the index.
+func (n planNode) usesIndex(indexName string) bool {
+	uses := false
+	n.walk(func(node planNode) {
+		if node.IndexName == indexName {
+			uses = true
+		}
+	})
+	return uses
+}
+
+// seqScans returns the relations scanned sequentially by the plan.
+func (n planNode) seqScans() []string {
+	var relations []string
+	n.walk(func(node planNode) {
+		if node.NodeType == "Seq Scan" {
+			relations = append(relations, node.RelationName)
+		}
+	})
+	return relations
+}
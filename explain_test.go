@@ -0,0 +1,44 @@
+package postgrestest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertUsesIndex(t *testing.T) {
+	t.Parallel()
+	db := Open(t, NewPostgresTest(t))
+	_, err := db.Exec(`CREATE TABLE items (id int PRIMARY KEY, name text NOT NULL);
+INSERT INTO items SELECT i, 'item ' || i FROM generate_series(1, 10000) i;
+ANALYZE items;`)
+	require.NoError(t, err)
+	AssertUsesIndex(t, db, `SELECT * FROM items WHERE id = $1`, "items_pkey", 42)
+	AssertNoSeqScan(t, db, `SELECT * FROM items WHERE id = $1`, 42)
+	ft := &fakeT{}
+	ft.run(func() {
+		AssertUsesIndex(ft, db, `SELECT * FROM items WHERE name = $1`, "items_pkey", "item 42")
+	})
+	require.Contains(t, ft.failures(), "the query doesn't use the index items_pkey")
+	require.Contains(t, ft.failures(), `"Node Type": "Seq Scan"`)
+	ft = &fakeT{}
+	ft.run(func() {
+		AssertNoSeqScan(ft, db, `SELECT * FROM items WHERE name = $1`, "item 42")
+	})
+	require.Contains(t, ft.failures(), "the query has a sequential scan on [items]")
+}
+
+func TestParsePlan(t *testing.T) {
+	t.Parallel()
+	plan, formatted, err := parsePlan([]byte(`[{"Plan": {"Node Type": "Nested Loop", "Plans": [
+		{"Node Type": "Seq Scan", "Relation Name": "orders"},
+		{"Node Type": "Index Only Scan", "Relation Name": "users", "Index Name": "users_pkey"}
+	]}}]`))
+	require.NoError(t, err)
+	require.True(t, plan.usesIndex("users_pkey"))
+	require.False(t, plan.usesIndex("orders_pkey"))
+	require.Equal(t, []string{"orders"}, plan.seqScans())
+	require.Contains(t, formatted, "\n          \"Node Type\": \"Seq Scan\",")
+	_, _, err = parsePlan([]byte(`[]`))
+	require.ErrorContains(t, err, "expected a single plan, got 0")
+}
@@ -0,0 +1,292 @@
+package postgrestest
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadFixtures inserts the rows described by the YAML or JSON files of fsys matching
+// the glob, like "fixtures/*.yaml", in a single transaction. Each file holds the rows
+// of the table named after it, like users.yaml or billing.invoices.json, as a mapping
+// of row labels to their columns:
+//
+//	alice:
+//	  name: Alice
+//	  email: alice@example.com
+//
+// A column can reference a column of another row, including generated ones like
+// serial ids, with a {ref: table.label.column} value:
+//
+//	first:
+//	  user_id: {ref: users.alice.id}
+//	  total: 10
+//
+// The tables are loaded in dependency order, so the referenced rows are inserted
+// first, and the rows of a table in the order of the file. Mapping and sequence
+// values, other than references, are inserted as JSON.
+func LoadFixtures(t TestingT, db *sql.DB, fsys fs.FS, glob string) {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	require.NoError(t, loadFixtures(db, fsys, glob))
+}
+
+// fixtureTable is a table with its rows, as read from its fixture file.
+type fixtureTable struct {
+	name string
+	file string
+	rows []fixtureRow
+	// dependencies are the other tables referenced by the rows.
+	dependencies []string
+}
+
+// fixtureRow is a labeled row, with the columns in the order of the file.
+type fixtureRow struct {
+	label   string
+	columns []string
+	values  []interface{}
+}
+
+// fixtureRef is a reference to the column of another row.
+type fixtureRef struct {
+	table  string
+	label  string
+	column string
+}
+
+// loadFixtures reads and inserts the fixtures matching the glob.
+func loadFixtures(db *sql.DB, fsys fs.FS, glob string) error {
+	files, err := fs.Glob(fsys, glob)
+	if err != nil {
+		return fmt.Errorf("invalid fixtures glob %q: %w", glob, err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no fixtures match %q", glob)
+	}
+	tables := make(map[string]*fixtureTable, len(files))
+	for _, file := range files {
+		table, err := readFixtureFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("fixture %s: %w", file, err)
+		}
+		if other, ok := tables[table.name]; ok {
+			return fmt.Errorf("fixtures %s and %s are both for the table %s", other.file, file, table.name)
+		}
+		tables[table.name] = table
+	}
+	order, err := sortFixtureTables(tables)
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	// inserted holds the inserted rows by table and label, with their returned columns
+	inserted := make(map[string]map[string]map[string]interface{}, len(tables))
+	for _, table := range order {
+		inserted[table.name] = make(map[string]map[string]interface{}, len(table.rows))
+		for _, row := range table.rows {
+			values, err := row.insert(tx, table.name, inserted)
+			if err != nil {
+				return fmt.Errorf("fixture %s, row %s: %w", table.file, row.label, err)
+			}
+			inserted[table.name][row.label] = values
+		}
+	}
+	return tx.Commit()
+}
+
+// readFixtureFile reads the rows of the fixture file, the table is the file name without the extension.
+func readFixtureFile(fsys fs.FS, file string) (*fixtureTable, error) {
+	content, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return nil, err
+	}
+	table := &fixtureTable{
+		name: strings.TrimSuffix(path.Base(file), path.Ext(file)),
+		file: file,
+	}
+	// JSON is valid YAML, a single decoder handles both
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		return table, nil
+	}
+	rows := document.Content[0]
+	if rows.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: the rows must be a mapping of labels to columns", rows.Line)
+	}
+	dependencies := make(map[string]bool)
+	for i := 0; i < len(rows.Content); i += 2 {
+		row := fixtureRow{label: rows.Content[i].Value}
+		columns := rows.Content[i+1]
+		if columns.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: the row %s must be a mapping of columns to values", columns.Line, row.label)
+		}
+		for j := 0; j < len(columns.Content); j += 2 {
+			column, valueNode := columns.Content[j].Value, columns.Content[j+1]
+			value, err := fixtureValue(valueNode)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: column %s: %w", valueNode.Line, column, err)
+			}
+			if ref, ok := value.(fixtureRef); ok && ref.table != table.name {
+				dependencies[ref.table] = true
+			}
+			row.columns = append(row.columns, column)
+			row.values = append(row.values, value)
+		}
+		table.rows = append(table.rows, row)
+	}
+	for dependency := range dependencies {
+		table.dependencies = append(table.dependencies, dependency)
+	}
+	sort.Strings(table.dependencies)
+	return table, nil
+}
+
+// fixtureValue decodes the value of a column, references are returned as fixtureRef
+// and the other mappings and sequences as JSON.
+func fixtureValue(node *yaml.Node) (interface{}, error) {
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["ref"].(string); ok && len(v) == 1 {
+			parts := strings.Split(ref, ".")
+			// the table may be schema qualified
+			if len(parts) != 3 && len(parts) != 4 {
+				return nil, fmt.Errorf("invalid reference %q, it must be table.label.column", ref)
+			}
+			n := len(parts)
+			return fixtureRef{table: strings.Join(parts[:n-2], "."), label: parts[n-2], column: parts[n-1]}, nil
+		}
+		return fixtureJSON(v)
+	case []interface{}:
+		return fixtureJSON(v)
+	}
+	return value, nil
+}
+
+// fixtureJSON encodes the value as JSON, as a string, so it can be inserted on json and text columns.
+func fixtureJSON(value interface{}) (interface{}, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// sortFixtureTables returns the tables sorted so the referenced tables come first.
+func sortFixtureTables(tables map[string]*fixtureTable) ([]*fixtureTable, error) {
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	order := make([]*fixtureTable, 0, len(tables))
+	// visiting holds the tables on the current path, to detect cycles
+	visiting, visited := make(map[string]bool), make(map[string]bool)
+	var visit func(name string, chain []string) error
+	visit = func(name string, chain []string) error {
+		if visited[name] {
+			return nil
+		}
+		chain = append(chain, name)
+		if visiting[name] {
+			return fmt.Errorf("the fixtures have a reference cycle: %s", strings.Join(chain, " -> "))
+		}
+		table, ok := tables[name]
+		if !ok {
+			return fmt.Errorf("the fixtures of %s reference the table %s, which has no fixtures", chain[len(chain)-2], name)
+		}
+		visiting[name] = true
+		for _, dependency := range table.dependencies {
+			if err := visit(dependency, chain); err != nil {
+				return err
+			}
+		}
+		visiting[name] = false
+		visited[name] = true
+		order = append(order, table)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// insert inserts the row, resolving its references, and returns its columns as stored.
+func (r fixtureRow) insert(tx *sql.Tx, table string, inserted map[string]map[string]map[string]interface{}) (map[string]interface{}, error) {
+	columns := make([]string, len(r.columns))
+	placeholders := make([]string, len(r.columns))
+	args := make([]interface{}, len(r.values))
+	for i, value := range r.values {
+		if ref, ok := value.(fixtureRef); ok {
+			row, ok := inserted[ref.table][ref.label]
+			if !ok {
+				// the rows of the same table are inserted in order, only the earlier ones can be referenced
+				return nil, fmt.Errorf("unknown reference to the row %s of %s", ref.label, ref.table)
+			}
+			if value, ok = row[ref.column]; !ok {
+				return nil, fmt.Errorf("unknown reference to the column %s of the row %s of %s", ref.column, ref.label, ref.table)
+			}
+		}
+		columns[i] = quoteIdentifier(r.columns[i])
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = value
+	}
+	query := `INSERT INTO ` + quoteQualifiedIdentifier(table) + ` (` + strings.Join(columns, ", ") + `) VALUES (` +
+		strings.Join(placeholders, ", ") + `) RETURNING *;`
+	if len(columns) == 0 {
+		query = `INSERT INTO ` + quoteQualifiedIdentifier(table) + ` DEFAULT VALUES RETURNING *;`
+	}
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("the insert returned no rows")
+	}
+	values := make([]interface{}, len(names))
+	pointers := make([]interface{}, len(names))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if err := rows.Scan(pointers...); err != nil {
+		return nil, err
+	}
+	stored := make(map[string]interface{}, len(names))
+	for i, name := range names {
+		stored[name] = values[i]
+	}
+	return stored, rows.Close()
+}
@@ -0,0 +1,114 @@
+package postgrestest
+
+import (
+	"database/sql"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fixturesFS has orders referencing users, and categories referencing themselves.
+var fixturesFS = fstest.MapFS{
+	"fixtures/orders.yaml": {Data: []byte(`first:
+  user_id: {ref: users.alice.id}
+  total: 10
+second:
+  user_id: {ref: users.bob.id}
+  total: 20
+  details: {gift: true}
+`)},
+	"fixtures/users.json": {Data: []byte(`{
+  "alice": {"name": "Alice"},
+  "bob": {"name": "Bob"}
+}`)},
+	"fixtures/categories.yaml": {Data: []byte(`root:
+  name: Root
+child:
+  name: Child
+  parent_id: {ref: categories.root.id}
+`)},
+}
+
+func TestLoadFixtures(t *testing.T) {
+	t.Parallel()
+	db := NewPostgresTestDB(t)
+	_, err := db.Exec(`CREATE TABLE users (id serial PRIMARY KEY, name text NOT NULL);
+CREATE TABLE orders (id serial PRIMARY KEY, user_id int NOT NULL REFERENCES users (id), total int NOT NULL, details jsonb);
+CREATE TABLE categories (id serial PRIMARY KEY, name text NOT NULL, parent_id int REFERENCES categories (id));`)
+	require.NoError(t, err)
+	LoadFixtures(t, db, fixturesFS, "fixtures/*")
+	var name string
+	require.NoError(t, db.QueryRow(`SELECT u.name FROM orders o JOIN users u ON u.id = o.user_id WHERE o.total = 20;`).Scan(&name))
+	require.Equal(t, "Bob", name)
+	var gift bool
+	require.NoError(t, db.QueryRow(`SELECT (details->>'gift')::bool FROM orders WHERE total = 20;`).Scan(&gift))
+	require.True(t, gift)
+	require.NoError(t, db.QueryRow(`SELECT p.name FROM categories c JOIN categories p ON p.id = c.parent_id;`).Scan(&name))
+	require.Equal(t, "Root", name)
+}
+
+func TestLoadFixturesSQLite(t *testing.T) {
+	t.Parallel()
+	db, err := sql.Open("sqlite", "file:load_fixtures?mode=memory&cache=shared")
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE users (id integer PRIMARY KEY AUTOINCREMENT, name text NOT NULL);
+CREATE TABLE orders (id integer PRIMARY KEY AUTOINCREMENT, user_id int NOT NULL REFERENCES users (id), total int NOT NULL, details text);
+CREATE TABLE categories (id integer PRIMARY KEY AUTOINCREMENT, name text NOT NULL, parent_id int REFERENCES categories (id));`)
+	require.NoError(t, err)
+	require.NoError(t, loadFixtures(db, fixturesFS, "fixtures/*"))
+	var name, details string
+	require.NoError(t, db.QueryRow(`SELECT u.name, o.details FROM orders o JOIN users u ON u.id = o.user_id WHERE o.total = 20;`).Scan(&name, &details))
+	require.Equal(t, "Bob", name)
+	require.Equal(t, `{"gift":true}`, details)
+}
+
+func TestLoadFixturesErrors(t *testing.T) {
+	t.Parallel()
+	db, err := sql.Open("sqlite", "file:load_fixtures_errors?mode=memory&cache=shared")
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE users (id integer PRIMARY KEY AUTOINCREMENT, name text NOT NULL, manager_id int);`)
+	require.NoError(t, err)
+	for name, tc := range map[string]struct {
+		fsys fstest.MapFS
+		err  string
+	}{
+		"no match": {
+			fsys: fstest.MapFS{},
+			err:  `no fixtures match "*.yaml"`,
+		},
+		"cycle": {
+			fsys: fstest.MapFS{
+				"a.yaml": {Data: []byte("row:\n  b_id: {ref: b.row.id}\n")},
+				"b.yaml": {Data: []byte("row:\n  a_id: {ref: a.row.id}\n")},
+			},
+			err: "the fixtures have a reference cycle: a -> b -> a",
+		},
+		"missing table": {
+			fsys: fstest.MapFS{"a.yaml": {Data: []byte("row:\n  b_id: {ref: b.row.id}\n")}},
+			err:  "the fixtures of a reference the table b, which has no fixtures",
+		},
+		"invalid reference": {
+			fsys: fstest.MapFS{"users.yaml": {Data: []byte("alice:\n  manager_id: {ref: users.bob}\n")}},
+			err:  `fixture users.yaml: line 2: column manager_id: invalid reference "users.bob", it must be table.label.column`,
+		},
+		"later row": {
+			fsys: fstest.MapFS{"users.yaml": {Data: []byte("alice:\n  name: Alice\n  manager_id: {ref: users.bob.id}\nbob:\n  name: Bob\n")}},
+			err:  "fixture users.yaml, row alice: unknown reference to the row bob of users",
+		},
+		"not a mapping": {
+			fsys: fstest.MapFS{"users.yaml": {Data: []byte("- name: Alice\n")}},
+			err:  "fixture users.yaml: line 1: the rows must be a mapping of labels to columns",
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			require.EqualError(t, loadFixtures(db, tc.fsys, "*.yaml"), tc.err)
+		})
+	}
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM users;`).Scan(&count))
+	require.Equal(t, 0, count)
+}
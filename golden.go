@@ -0,0 +1,134 @@
+package postgrestest
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/stretchr/testify/require"
+)
+
+// updateGoldenEnv is the environment variable that makes GoldenQuery write the golden files.
+const updateGoldenEnv = "POSTGRESTEST_UPDATE_GOLDEN"
+
+// GoldenQuery runs the query and compares its result to the golden file
+// testdata/<name>.golden, failing the test with the differences, so the output of
+// complex queries, like reports, is locked in without writing the rows by hand:
+//
+//	postgrestest.GoldenQuery(t, db, "monthly_revenue", `SELECT month, total FROM monthly_revenue($1) ORDER BY month;`, 2023)
+//
+// The result is written as a JSON array with the column names followed by a line with
+// the values of each row, as their text, or null. Times are formatted as RFC 3339 with
+// nanoseconds in UTC, so the files are the same on every machine. The rows are kept
+// in the order the query returns them, it must have an ORDER BY to be deterministic.
+// The golden files are written with the current results instead when the test package
+// defines an -update flag and it's set, or when POSTGRESTEST_UPDATE_GOLDEN is set:
+//
+//	var _ = flag.Bool("update", false, "update the golden files")
+func GoldenQuery(t TestingT, db *sql.DB, name string, query string, args ...interface{}) {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	goldenQuery(t, db, filepath.Join("testdata", name+".golden"), updateGolden(), query, args...)
+}
+
+// updateGolden returns whether the golden files must be written, with the -update flag
+// of the test package or the environment variable.
+func updateGolden() bool {
+	if os.Getenv(updateGoldenEnv) != "" {
+		return true
+	}
+	f := flag.Lookup("update")
+	if f == nil {
+		return false
+	}
+	update, _ := strconv.ParseBool(f.Value.String())
+	return update
+}
+
+// goldenQuery compares the result of the query to the golden file, or writes it when updating.
+func goldenQuery(t TestingT, db *sql.DB, path string, update bool, query string, args ...interface{}) {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	got, err := queryJSON(db, query, args...)
+	require.NoError(t, err, "failed to query %s", query)
+	if update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+		return
+	}
+	want, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		require.Fail(t, fmt.Sprintf("the golden file %s doesn't exist, run the tests with -update to create it", path))
+	}
+	require.NoError(t, err)
+	require.Equal(t, string(want), string(got), "the result doesn't match the golden file %s, run the tests with -update to update it", path)
+}
+
+// queryJSON returns the result of the query as a JSON array of arrays, the column
+// names first, with a line for each row.
+func queryJSON(db *sql.DB, query string, args ...interface{}) ([]byte, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	header, err := json.Marshal(columns)
+	if err != nil {
+		return nil, err
+	}
+	lines := [][]byte{header}
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	record := make([]*string, len(columns))
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		for i, value := range values {
+			record[i] = nil
+			if text, ok := valueText(value); ok {
+				record[i] = &text
+			}
+		}
+		line, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	b.WriteString("[\n")
+	for i, line := range lines {
+		b.WriteString("  ")
+		b.Write(line)
+		if i < len(lines)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("]\n")
+	return b.Bytes(), nil
+}
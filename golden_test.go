@@ -0,0 +1,44 @@
+package postgrestest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoldenQuery(t *testing.T) {
+	t.Parallel()
+	db := NewPostgresTestDB(t, WithEngine(EngineSQLite), WithDriverName("sqlite"))
+	_, err := db.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT);
+INSERT INTO items (id, name) VALUES (1, 'a, b'), (2, NULL);`)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "testdata", "items.golden")
+	query := `SELECT id, name FROM items WHERE id >= $1 ORDER BY id;`
+	ft := &fakeT{}
+	ft.run(func() {
+		goldenQuery(ft, db, path, false, query, 1)
+	})
+	require.Contains(t, ft.failures(), "items.golden doesn't exist, run the tests with -update to create it")
+	goldenQuery(t, db, path, true, query, 1)
+	golden, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "[\n  [\"id\",\"name\"],\n  [\"1\",\"a, b\"],\n  [\"2\",null]\n]\n", string(golden))
+	goldenQuery(t, db, path, false, query, 1)
+	_, err = db.Exec(`UPDATE items SET name = 'c' WHERE id = 2;`)
+	require.NoError(t, err)
+	ft = &fakeT{}
+	ft.run(func() {
+		goldenQuery(ft, db, path, false, query, 1)
+	})
+	require.Contains(t, ft.failures(), "run the tests with -update to update it")
+	require.Contains(t, ft.failures(), `+  ["2","c"]`)
+}
+
+func TestUpdateGolden(t *testing.T) {
+	t.Setenv(updateGoldenEnv, "")
+	require.False(t, updateGolden())
+	t.Setenv(updateGoldenEnv, "1")
+	require.True(t, updateGolden())
+}
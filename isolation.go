@@ -0,0 +1,127 @@
+package postgrestest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/stretchr/testify/require"
+)
+
+// WithSchemaIsolation is an option that isolates the test on a new schema of the
+// maintenance database, instead of on a new database. The returned DSN points to the
+// maintenance database with the search_path set to the schema, which is dropped with
+// CASCADE on the test cleanup. Creating a schema is much faster than creating a
+// database, and works on hosted servers where CREATE DATABASE is slow or not allowed.
+// The shared database can be chosen with WithMaintenanceDB or the TESTING_POSTGRES_DB
+// environment variable. The options that configure the database, like WithComment
+// or WithTemplate, don't apply, the setup functions, like WithMigrations, run with
+// the search_path set to the schema.
+// Objects created with a schema qualified name, or on other schemas, aren't isolated.
+func WithSchemaIsolation() Option {
+	return func(opts *options) {
+		opts.schemaIsolation = true
+	}
+}
+
+// createSchema creates the test schema on the maintenance database, returning
+// the DSN for it and a function that drops it, only the first call drops it.
+func (o *options) createSchema(ctx context.Context, t TestingT) (string, func(), error) {
+	if err := o.provision(); err != nil {
+		return "", nil, err
+	}
+	schema, err := randomName(t, o, "testing_schema_")
+	if err != nil {
+		return "", nil, err
+	}
+	if err := checkDatabaseNameLength(o, schema); err != nil {
+		return "", nil, err
+	}
+	address, err := BuildDSN(o.baseAddress, o.maintenanceDatabase, map[string]string{"search_path": schema})
+	if err != nil {
+		return "", nil, err
+	}
+	dsn, err := o.formatDSN(address)
+	if err != nil {
+		return "", nil, err
+	}
+	o.holdBaseDB()
+	release := func() {
+		if o.releaseBaseDB != nil {
+			o.releaseBaseDB()
+		}
+	}
+	if o.waitTimeout > 0 {
+		db, closeDB, err := o.openBaseDB()
+		if err == nil {
+			err = o.waitForBaseServer(ctx, db)
+			closeDB()
+		}
+		if err != nil {
+			release()
+			return "", nil, err
+		}
+	}
+	if err := o.execOnBaseDB(ctx, `CREATE SCHEMA `+quoteIdentifier(schema)+`;`); err != nil {
+		release()
+		return "", nil, fmt.Errorf("failed to create the test schema: %w", err)
+	}
+	var once sync.Once
+	cleanup := func() {
+		once.Do(func() {
+			defer release()
+			if o.retain(t, "schema "+schema, func() (string, error) { return dsn, nil }) {
+				return
+			}
+			ctx, cancel := o.cleanupContext()
+			defer cancel()
+			if err := o.execOnBaseDB(ctx, `DROP SCHEMA `+quoteIdentifier(schema)+` CASCADE;`); err != nil {
+				err = fmt.Errorf("postgrestest cleanup: failed to drop schema %s: %w", schema, err)
+				if o.cleanupErrorHandler != nil {
+					o.cleanupErrorHandler(err)
+					return
+				}
+				require.NoError(t, err)
+			}
+		})
+	}
+	if err := setupSchema(ctx, o, address); err != nil {
+		ctx, cancel := o.cleanupContext()
+		defer cancel()
+		if dropErr := o.execOnBaseDB(ctx, `DROP SCHEMA `+quoteIdentifier(schema)+` CASCADE;`); dropErr != nil {
+			logf(t, "postgrestest: failed to drop the test schema %s after a failed setup: %v", schema, dropErr)
+		}
+		release()
+		return "", nil, err
+	}
+	return dsn, cleanup, nil
+}
+
+// execOnBaseDB executes the statement on the base database.
+func (o *options) execOnBaseDB(ctx context.Context, statement string) error {
+	db, closeDB, err := o.openBaseDB()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	_, err = db.ExecContext(ctx, statement)
+	return err
+}
+
+// setupSchema runs the setup functions connected with the search_path set to the test schema.
+func setupSchema(ctx context.Context, opts *options, address string) error {
+	if len(opts.setupFunctions) == 0 {
+		return nil
+	}
+	db, err := opts.connect(address)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	for _, setup := range opts.setupFunctions {
+		if err := setup(ctx, db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
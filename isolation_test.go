@@ -0,0 +1,39 @@
+package postgrestest
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSchemaIsolation(t *testing.T) {
+	t.Parallel()
+	migrations := fstest.MapFS{
+		"migrations/001_items.sql": {Data: []byte(`CREATE TABLE items (id serial PRIMARY KEY, name text NOT NULL);`)},
+	}
+	testDB, cleanup := NewPostgresTestWithCleanup(t, WithSchemaIsolation(), WithMigrations(migrations, "migrations/*.sql"))
+	db, err := sql.Open("pgx", testDB)
+	require.NoError(t, err)
+	var schema string
+	require.NoError(t, db.QueryRow(`SELECT current_schema();`).Scan(&schema))
+	require.True(t, strings.HasPrefix(schema, "testing_schema_testwithschemaisolation_"), schema)
+	_, err = db.Exec(`INSERT INTO items (name) VALUES ('a');`)
+	require.NoError(t, err)
+	other := Open(t, NewPostgresTest(t, WithSchemaIsolation()))
+	_, err = other.Exec(`SELECT 1 FROM items;`)
+	require.Error(t, err)
+	require.NoError(t, db.Close())
+	cleanup()
+	var exists bool
+	require.NoError(t, other.QueryRow(`SELECT EXISTS (SELECT 1 FROM pg_namespace WHERE nspname = $1);`, schema).Scan(&exists))
+	require.False(t, exists)
+}
+
+func TestWithSchemaIsolationScrambledSearchPath(t *testing.T) {
+	t.Parallel()
+	_, err := newOptions([]Option{WithSchemaIsolation(), WithScrambledSearchPath()})
+	require.ErrorContains(t, err, "the scrambled search path can't be used with the schema isolation")
+}
@@ -0,0 +1,39 @@
+package postgrestest
+
+import (
+	_ "github.com/lib/pq" // lib/pq driver, registered as postgres
+)
+
+// libPQDriverName is the name of the lib/pq database/sql driver.
+const libPQDriverName = "postgres"
+
+// WithLibPQ is an option that makes the package use the lib/pq driver, registered
+// as postgres, like WithDriverName("postgres"), for legacy codebases still using it.
+// Unlike pgx, lib/pq requires SSL by default, so sslmode=disable is added to the base
+// address and the returned DSN, unless the base address or WithConnectionParams
+// provide a sslmode.
+func WithLibPQ() Option {
+	return func(opts *options) {
+		opts.driverName = libPQDriverName
+		opts.libPQ = true
+	}
+}
+
+// applyLibPQ adds the lib/pq connection params the base address lacks.
+func (o *options) applyLibPQ() error {
+	if !o.libPQ {
+		return nil
+	}
+	if _, ok := o.connectionParams["sslmode"]; ok {
+		return nil
+	}
+	u, err := parseBaseAddress(o.baseAddress)
+	if err != nil {
+		return err
+	}
+	if u.Query().Has("sslmode") {
+		return nil
+	}
+	WithConnectionParams(map[string]string{"sslmode": "disable"})(o)
+	return nil
+}
@@ -0,0 +1,80 @@
+package postgrestest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// RunMain runs the tests of the package against a base server set up once for the
+// whole run, and exits with the result, it's meant to be the whole TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		postgrestest.RunMain(m, postgrestest.WithDockerProvisioner())
+//	}
+//
+// The base server is resolved from the options like NewPostgresTest does. When it
+// isn't reachable and WithDockerProvisioner is used, a container is started for the
+// run, with the version of WithPostgresVersion, and removed after it unless
+// WithDockerReuse is used. WithWaitTimeout waits for the server before running the
+// tests. The address is stored on the TESTING_POSTGRES_TEST environment variable, so
+// the tests, and the processes they start, use it without options.
+// After the tests, the deletes queued by WithAsyncCleanup are waited for, failing the
+// run when any of them failed.
+func RunMain(m interface{ Run() int }, opts ...Option) {
+	os.Exit(runMain(m, os.Stderr, opts))
+}
+
+// runMain runs the tests, returning the exit code, the errors are written to stderr.
+func runMain(m interface{ Run() int }, stderr io.Writer, opts []Option) int {
+	o, err := newOptions(opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "postgrestest: %v\n", err)
+		return 1
+	}
+	address := o.baseAddress
+	if o.dockerProvisioner && (o.postgresVersion != "" || !baseAddressReachable(address)) {
+		if !o.dockerReuse {
+			name, _ := dockerServer(o.postgresVersion, false)
+			defer func() {
+				if err := stopDockerServer(name); err != nil {
+					fmt.Fprintf(stderr, "postgrestest: failed to remove the container %s: %v\n", name, err)
+				}
+			}()
+		}
+		address, err = provisionDockerServer(o.postgresVersion, o.dockerReuse)
+		if err != nil {
+			fmt.Fprintf(stderr, "postgrestest: %v\n", err)
+			return 1
+		}
+	}
+	if previous, ok := os.LookupEnv(baseAddressEnv); ok {
+		defer os.Setenv(baseAddressEnv, previous)
+	} else {
+		defer os.Unsetenv(baseAddressEnv)
+	}
+	if err := os.Setenv(baseAddressEnv, address); err != nil {
+		fmt.Fprintf(stderr, "postgrestest: %v\n", err)
+		return 1
+	}
+	if o.waitTimeout > 0 {
+		db, err := DefaultConnectFunction(address)
+		if err == nil {
+			err = o.waitForBaseServer(context.Background(), db)
+			_ = db.Close()
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "postgrestest: %v\n", err)
+			return 1
+		}
+	}
+	code := m.Run()
+	if err := FlushCleanups(); err != nil {
+		fmt.Fprintf(stderr, "postgrestest: %v\n", err)
+		if code == 0 {
+			code = 1
+		}
+	}
+	return code
+}
@@ -0,0 +1,132 @@
+package postgrestest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+)
+
+// WithMigrations is an option that runs the SQL files of fsys matching the glob,
+// like "migrations/*.sql", on the test database in lexical order, each one in its
+// own transaction. Only the matching files with the .sql extension run, so a glob
+// like "migrations/*" skips the READMEs and directories next to them. It runs with
+// the other setup functions, using the migration role when provided. By default all
+// the files run every time, see WithMigrationTracking for reused databases.
+func WithMigrations(fsys fs.FS, glob string) Option {
+	return func(opts *options) {
+		opts.setupFunctions = append(opts.setupFunctions, func(ctx context.Context, db *sql.DB) error {
+			return runMigrations(ctx, db, fsys, glob, opts.migrationTrackingTable)
+		})
+	}
+}
+
+// WithMigrationTracking is an option that records the migrations applied by
+// WithMigrations on the table, creating it when needed, and skips the ones
+// already applied. It allows running migrations on databases reused with
+// WithReuseExisting, applying only the new files. The table can be schema qualified.
+func WithMigrationTracking(tableName string) Option {
+	return func(opts *options) {
+		opts.migrationTrackingTable = tableName
+	}
+}
+
+// validTableName matches unquoted table names, optionally schema qualified.
+var validTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// runMigrations runs the migrations matching the glob, skipping the ones recorded
+// on the tracking table when provided.
+func runMigrations(ctx context.Context, db *sql.DB, fsys fs.FS, glob, trackingTable string) error {
+	matches, err := fs.Glob(fsys, glob)
+	if err != nil {
+		return fmt.Errorf("invalid migrations glob %q: %w", glob, err)
+	}
+	var files []string
+	for _, file := range matches {
+		if path.Ext(file) != ".sql" {
+			continue
+		}
+		info, err := fs.Stat(fsys, file)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			continue
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no migrations match %q", glob)
+	}
+	sort.Strings(files)
+	applied := make(map[string]bool)
+	if trackingTable != "" {
+		if applied, err = appliedMigrations(ctx, db, trackingTable); err != nil {
+			return err
+		}
+	}
+	for _, file := range files {
+		if applied[file] {
+			continue
+		}
+		if err := runMigration(ctx, db, fsys, file, trackingTable); err != nil {
+			return fmt.Errorf("migration %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// appliedMigrations creates the tracking table when needed and returns the migrations recorded on it.
+func appliedMigrations(ctx context.Context, db *sql.DB, trackingTable string) (map[string]bool, error) {
+	if !validTableName.MatchString(trackingTable) {
+		return nil, fmt.Errorf("invalid migration tracking table name %q", trackingTable)
+	}
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+trackingTable+` (
+	name text PRIMARY KEY,
+	applied_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the migration tracking table: %w", err)
+	}
+	rows, err := db.QueryContext(ctx, `SELECT name FROM `+trackingTable+`;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		applied[name] = true
+	}
+	return applied, rows.Err()
+}
+
+// runMigration runs the migration file in a transaction, recording it on the tracking table when provided.
+func runMigration(ctx context.Context, db *sql.DB, fsys fs.FS, file, trackingTable string) error {
+	content, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return err
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if _, err := tx.ExecContext(ctx, string(content)); err != nil {
+		return err
+	}
+	if trackingTable != "" {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO `+trackingTable+` (name) VALUES ($1);`, file); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
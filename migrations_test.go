@@ -0,0 +1,90 @@
+package postgrestest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMigrationTracking(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"migrations/001_items.sql": {Data: []byte(`CREATE TABLE items (id serial PRIMARY KEY, name text NOT NULL);
+INSERT INTO items (name) VALUES ('first');`)},
+	}
+	databaseName := fmt.Sprintf("testing_db_migrations_%d", time.Now().UnixNano())
+	opts := []Option{
+		WithDatabaseName(databaseName),
+		WithReuseExisting(),
+		WithMigrations(fsys, "migrations/*.sql"),
+		WithMigrationTracking("schema_migrations"),
+	}
+	baseDB := Open(t, testBaseAddress())
+	defer func() {
+		require.NoError(t, ForceDeleteDatabaseFunction(baseDB, databaseName))
+	}()
+	NewPostgresTest(t, opts...)
+	// the first migration is not idempotent, running it again would fail
+	fsys["migrations/002_more_items.sql"] = &fstest.MapFile{Data: []byte(`INSERT INTO items (name) VALUES ('second');`)}
+	db, err := sql.Open("pgx", NewPostgresTest(t, opts...))
+	require.NoError(t, err)
+	defer db.Close()
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM items;`).Scan(&count))
+	require.Equal(t, 2, count)
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM schema_migrations;`).Scan(&count))
+	require.Equal(t, 2, count)
+}
+
+func TestRunMigrations(t *testing.T) {
+	t.Parallel()
+	db, err := sql.Open("sqlite", "file:run_migrations?mode=memory&cache=shared")
+	require.NoError(t, err)
+	defer db.Close()
+	fsys := fstest.MapFS{
+		"002_insert.sql":  {Data: []byte(`INSERT INTO items (name) VALUES ('a');`)},
+		"001_create.sql":  {Data: []byte(`CREATE TABLE items (name text NOT NULL);`)},
+		"README.md":       {Data: []byte(`not a migration`)},
+		"old.sql/001.sql": {Data: []byte(`not a migration`)},
+	}
+	require.NoError(t, runMigrations(context.Background(), db, fsys, "*", "schema_migrations"))
+	require.NoError(t, runMigrations(context.Background(), db, fsys, "*.sql", "schema_migrations"))
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM items;`).Scan(&count))
+	require.Equal(t, 1, count)
+	fsys["003_fail.sql"] = &fstest.MapFile{Data: []byte(`INSERT INTO missing (name) VALUES ('a');`)}
+	err = runMigrations(context.Background(), db, fsys, "*.sql", "schema_migrations")
+	require.ErrorContains(t, err, "migration 003_fail.sql")
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM schema_migrations;`).Scan(&count))
+	require.Equal(t, 2, count)
+	require.EqualError(t, runMigrations(context.Background(), db, fsys, "*.yaml", ""), `no migrations match "*.yaml"`)
+	require.EqualError(t, runMigrations(context.Background(), db, fsys, "README*", ""), `no migrations match "README*"`)
+	require.EqualError(t, runMigrations(context.Background(), db, fsys, "*.sql", "bad name"), `invalid migration tracking table name "bad name"`)
+}
+
+func TestWithSeed(t *testing.T) {
+	t.Parallel()
+	migrations := fstest.MapFS{
+		"001_items.sql": {Data: []byte(`CREATE TABLE items (id serial PRIMARY KEY, name text NOT NULL);`)},
+	}
+	db := NewPostgresTestDB(t,
+		// the seeds run after the migrations, regardless of the order of the options
+		WithSeed(func(db *sql.DB) error {
+			_, err := db.Exec(`INSERT INTO items (name) VALUES ('a');`)
+			return err
+		}),
+		WithMigrations(migrations, "*.sql"),
+		WithSeed(func(db *sql.DB) error {
+			_, err := db.Exec(`UPDATE items SET name = name || 'b';`)
+			return err
+		}),
+	)
+	var name string
+	require.NoError(t, db.QueryRow(`SELECT name FROM items;`).Scan(&name))
+	require.Equal(t, "ab", name)
+}
@@ -0,0 +1,199 @@
+package postgrestest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Pool keeps test databases created ahead of time in the background, so handing one
+// out to a test doesn't wait for CREATE DATABASE and the setup functions, which
+// dominate the runtime of suites with hundreds of integration tests. It's meant to
+// be shared by the tests of a package, created and closed in TestMain:
+//
+//	var pool *postgrestest.Pool
+//
+//	func TestMain(m *testing.M) {
+//		var err error
+//		pool, err = postgrestest.NewPool(8, postgrestest.WithMigrations(migrations, "*.sql"))
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		code := m.Run()
+//		_ = pool.Close()
+//		os.Exit(code)
+//	}
+//
+//	func TestUsers(t *testing.T) {
+//		db := postgrestest.Open(t, pool.NewPostgresTest(t))
+//		// test code
+//	}
+//
+// The migrations can also run once with NewTemplate, creating the pooled databases
+// with WithTemplate.
+type Pool struct {
+	opts      []Option
+	databases chan pooledDatabase
+	// closing is closed by Close, so the tests waiting for a database fail.
+	closing chan struct{}
+	mu      sync.Mutex
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// pooledDatabase is a database created by the pool, or the error creating it.
+type pooledDatabase struct {
+	opts         *options
+	databaseName string
+	logs         []string
+	err          error
+}
+
+// NewPool returns a pool keeping size databases created with the options, it starts
+// creating them in the background. The options that name the database, isolate the
+// test on a schema or use SQLite can't be used.
+func NewPool(size int, opts ...Option) (*Pool, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid pool size %d, it must be positive", size)
+	}
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case o.engine == EngineSQLite:
+		return nil, errors.New("the pool can't be used with SQLite databases")
+	case o.schemaIsolation:
+		return nil, errors.New("the pool can't be used with WithSchemaIsolation")
+	case o.databaseName != "":
+		return nil, errors.New("the pool can't be used with WithDatabaseName, the pooled databases need unique names")
+	}
+	p := &Pool{
+		opts:      opts,
+		databases: make(chan pooledDatabase, size),
+		closing:   make(chan struct{}),
+	}
+	for i := 0; i < size; i++ {
+		p.fill()
+	}
+	return p, nil
+}
+
+// NewPostgresTest returns the DSN of a database of the pool, deleted on the test
+// cleanup like the ones of NewPostgresTest, and starts creating its replacement.
+// It waits for a database when none is ready, failing the test if creating it failed.
+func (p *Pool) NewPostgresTest(t TestingT) string {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	var database pooledDatabase
+	select {
+	case database = <-p.databases:
+	case <-p.closing:
+		require.Fail(t, "postgrestest: the pool is closed")
+	}
+	p.fill()
+	for _, log := range database.logs {
+		logf(t, "%s", log)
+	}
+	require.NoError(t, database.err, "postgrestest: failed to create the pooled database")
+	t.Cleanup(database.opts.cleanupFunction(t, database.databaseName))
+	dsn, err := database.opts.dsn(database.databaseName)
+	require.NoError(t, err)
+	return dsn
+}
+
+// Close stops creating databases and deletes the ones that weren't handed out, waiting
+// for the ones being created to finish first, so none is left behind when the process
+// exits right after. WithCreateTimeout bounds the wait on a hung server. The databases
+// handed out are deleted by the cleanup of their tests.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.closing)
+	p.mu.Unlock()
+	p.wg.Wait()
+	var errs []error
+	for {
+		select {
+		case database := <-p.databases:
+			if database.err != nil {
+				continue
+			}
+			if err := database.opts.deleteDatabaseWithTimeout(database.databaseName); err != nil {
+				errs = append(errs, cleanupError(database.databaseName, err))
+			}
+			if database.opts.releaseBaseDB != nil {
+				database.opts.releaseBaseDB()
+			}
+		default:
+			return errors.Join(errs...)
+		}
+	}
+}
+
+// fill creates a database in the background and adds it to the pool, unless the
+// pool is closed. The pool holds at most size databases ready or being created, so
+// adding it never blocks.
+func (p *Pool) fill() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		// each database has its own options, they hold the state used to delete it
+		o, err := newOptions(p.opts)
+		if err != nil {
+			p.databases <- pooledDatabase{err: err}
+			return
+		}
+		t := &poolT{}
+		databaseName, err := o.createDatabase(context.Background(), t)
+		p.databases <- pooledDatabase{opts: o, databaseName: databaseName, logs: t.logged(), err: err}
+	}()
+}
+
+// poolT is the TestingT used to create the databases of the pool, outside of any test.
+// The logs are replayed on the test the database is handed out to.
+type poolT struct {
+	mu   sync.Mutex
+	logs []string
+}
+
+func (p *poolT) Logf(format string, args ...interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.logs = append(p.logs, fmt.Sprintf(format, args...))
+}
+
+// logged returns a copy of the logs, the creation may still log after timing out.
+func (p *poolT) logged() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.logs...)
+}
+
+func (p *poolT) Errorf(format string, args ...interface{}) {
+	p.Logf(format, args...)
+}
+
+func (p *poolT) FailNow() {
+	runtime.Goexit()
+}
+
+// Cleanup isn't used when creating the databases, they're deleted by the test they
+// are handed out to or by Close.
+func (p *poolT) Cleanup(func()) {}
@@ -0,0 +1,116 @@
+package postgrestest
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPool(t *testing.T) {
+	t.Parallel()
+	pool, err := NewPool(2, WithTemplate("template1"))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, pool.Close())
+	}()
+	for i := 0; i < 3; i++ {
+		db := Open(t, pool.NewPostgresTest(t))
+		var one int
+		require.NoError(t, db.QueryRow(`SELECT 1;`).Scan(&one))
+	}
+}
+
+func TestPoolClose(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	created := make(map[string]bool)
+	pool, err := NewPool(3,
+		WithCreateDatabaseFunction(func(db *sql.DB, database string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			created[database] = true
+			return nil
+		}),
+		WithDeleteDatabaseFunction(func(db *sql.DB, database string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			delete(created, database)
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+	ft := &fakeT{}
+	var dsn string
+	ft.run(func() {
+		dsn = pool.NewPostgresTest(ft)
+	})
+	require.Empty(t, ft.failures())
+	require.Regexp(t, `/testing_db_[0-9a-f]{16}$`, dsn)
+	// the replacement is created in the background
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(created) == 4
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, pool.Close())
+	mu.Lock()
+	require.Len(t, created, 1)
+	mu.Unlock()
+	ft.runCleanups()
+	require.Empty(t, created)
+	ft = &fakeT{}
+	ft.run(func() {
+		pool.NewPostgresTest(ft)
+	})
+	require.Contains(t, ft.failures(), "postgrestest: the pool is closed")
+}
+
+func TestNewPoolInvalid(t *testing.T) {
+	t.Parallel()
+	_, err := NewPool(0)
+	require.EqualError(t, err, "invalid pool size 0, it must be positive")
+	_, err = NewPool(1, WithDatabaseName("testing_db"))
+	require.ErrorContains(t, err, "the pool can't be used with WithDatabaseName")
+	_, err = NewPool(1, WithEngine(EngineSQLite))
+	require.EqualError(t, err, "the pool can't be used with SQLite databases")
+}
+
+func TestPoolCloseWaitsForCreates(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	created := make(map[string]bool)
+	release := make(chan struct{})
+	pool, err := NewPool(1,
+		WithCreateDatabaseFunction(func(db *sql.DB, database string) error {
+			<-release
+			mu.Lock()
+			defer mu.Unlock()
+			created[database] = true
+			return nil
+		}),
+		WithDeleteDatabaseFunction(func(db *sql.DB, database string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			delete(created, database)
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+	closed := make(chan error, 1)
+	go func() {
+		closed <- pool.Close()
+	}()
+	select {
+	case <-closed:
+		require.Fail(t, "Close returned while a database was being created")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	require.NoError(t, <-closed)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Empty(t, created)
+}
@@ -0,0 +1,186 @@
+// Package postgrestestembedded provides the base server for postgrestest with an
+// embedded Postgres, run from binaries downloaded by embedded-postgres, so the tests
+// need neither Docker nor a Postgres server installed.
+package postgrestestembedded
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/crossworth/postgrestest"
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/stretchr/testify/require"
+)
+
+// Version is the version of the embedded server of WithEmbeddedPostgres.
+const Version = embeddedpostgres.V14
+
+// majorVersions are the exact versions used for the major versions, the ones of
+// embedded-postgres.
+var majorVersions = map[string]embeddedpostgres.PostgresVersion{
+	"10": embeddedpostgres.V10,
+	"11": embeddedpostgres.V11,
+	"12": embeddedpostgres.V12,
+	"13": embeddedpostgres.V13,
+	"14": embeddedpostgres.V14,
+	"15": embeddedpostgres.V15,
+}
+
+// server is an embedded server shared by the tests of the process.
+type server struct {
+	postgres    *embeddedpostgres.EmbeddedPostgres
+	runtimePath string
+	address     string
+}
+
+// servers holds the embedded servers by version, started on first use.
+var servers struct {
+	sync.Mutex
+	running map[embeddedpostgres.PostgresVersion]*server
+}
+
+// WithEmbeddedPostgres returns an option that uses an embedded Postgres as the base
+// server. The server is started once per test binary, the first time the option is
+// created, and shared by all of its tests. Its data lives under a temporary directory.
+// The binaries are downloaded on the first run and cached on the home directory,
+// under .embedded-postgres-go, the later runs work offline.
+// The server is a child process that outlives the test binary, it must be stopped
+// with Stop, like on TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		code := m.Run()
+//		_ = postgrestestembedded.Stop()
+//		os.Exit(code)
+//	}
+//
+// Postgres refuses to run as root, so it doesn't work on containers running as root.
+// Creating the option fails the test when the server can't be started.
+func WithEmbeddedPostgres(t postgrestest.TestingT) postgrestest.Option {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	address, err := baseAddress(Version)
+	require.NoError(t, err)
+	return postgrestest.WithFixedBaseAddress(address)
+}
+
+// WithEmbeddedPostgresVersion is like WithEmbeddedPostgres with the version of the
+// server, so the tests run on the version production runs. It's a major version like
+// "15", using the latest release embedded-postgres knows of, or an exact one like
+// "15.3", which must have binaries published by embedded-postgres. Each version has
+// its own server, they're all stopped by Stop.
+func WithEmbeddedPostgresVersion(t postgrestest.TestingT, version string) postgrestest.Option {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	v, err := parseVersion(version)
+	require.NoError(t, err)
+	address, err := baseAddress(v)
+	require.NoError(t, err)
+	return postgrestest.WithFixedBaseAddress(address)
+}
+
+// parseVersion returns the embedded-postgres version of a major or exact version.
+func parseVersion(version string) (embeddedpostgres.PostgresVersion, error) {
+	if err := postgrestest.ValidatePostgresVersion(version); err != nil {
+		return "", err
+	}
+	if !strings.Contains(version, ".") {
+		v, ok := majorVersions[version]
+		if !ok {
+			return "", fmt.Errorf("unknown Postgres major version %s, use an exact version like %s.0", version, version)
+		}
+		return v, nil
+	}
+	if strings.Count(version, ".") == 1 {
+		// the binaries are published with the patch version
+		version += ".0"
+	}
+	return embeddedpostgres.PostgresVersion(version), nil
+}
+
+// Stop stops the embedded servers and removes their data, it's a no-op when none is running.
+func Stop() error {
+	servers.Lock()
+	defer servers.Unlock()
+	var errs []error
+	for version, s := range servers.running {
+		if err := s.postgres.Stop(); err != nil {
+			errs = append(errs, err)
+		}
+		if err := os.RemoveAll(s.runtimePath); err != nil {
+			errs = append(errs, err)
+		}
+		delete(servers.running, version)
+	}
+	return errors.Join(errs...)
+}
+
+// baseAddress returns the base address of the shared server of the version, starting
+// it when needed. Failures aren't remembered, the next test tries again.
+func baseAddress(version embeddedpostgres.PostgresVersion) (string, error) {
+	servers.Lock()
+	defer servers.Unlock()
+	if s, ok := servers.running[version]; ok {
+		return s.address, nil
+	}
+	port, err := freePort()
+	if err != nil {
+		return "", fmt.Errorf("failed to start the embedded Postgres: %w", err)
+	}
+	runtimePath, err := os.MkdirTemp("", "postgrestest-embedded-")
+	if err != nil {
+		return "", fmt.Errorf("failed to start the embedded Postgres: %w", err)
+	}
+	postgres := embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+		Version(version).
+		Port(port).
+		Username("postgres").
+		Password("root").
+		Database("postgres").
+		RuntimePath(runtimePath).
+		// we disable a few options to make the database faster for testing, like docker-compose.yml
+		StartParameters(map[string]string{
+			"fsync":              "off",
+			"synchronous_commit": "off",
+			"full_page_writes":   "off",
+			"max_connections":    "500",
+		}).
+		StartTimeout(time.Minute).
+		Logger(io.Discard))
+	if err := postgres.Start(); err != nil {
+		_ = os.RemoveAll(runtimePath)
+		return "", fmt.Errorf("failed to start the embedded Postgres: %w", err)
+	}
+	if servers.running == nil {
+		servers.running = make(map[embeddedpostgres.PostgresVersion]*server)
+	}
+	s := &server{
+		postgres:    postgres,
+		runtimePath: runtimePath,
+		address:     "postgres://postgres:root@" + net.JoinHostPort("localhost", strconv.FormatUint(uint64(port), 10)),
+	}
+	servers.running[version] = s
+	return s.address, nil
+}
+
+// freePort returns a TCP port that is free on localhost.
+func freePort() (uint32, error) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return uint32(listener.Addr().(*net.TCPAddr).Port), nil
+}
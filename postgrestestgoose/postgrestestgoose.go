@@ -0,0 +1,43 @@
+// Package postgrestestgoose provides options for running goose migrations on the
+// test databases of postgrestest, from a directory or an fs.FS.
+package postgrestestgoose
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"sync"
+
+	"github.com/crossworth/postgrestest"
+	"github.com/pressly/goose/v3"
+)
+
+// gooseMu serializes the goose runs, since goose keeps the dialect and the base
+// filesystem on package level variables.
+var gooseMu sync.Mutex
+
+// WithGoose returns an option that applies the goose migrations of the directory
+// to the test database after creating it.
+func WithGoose(dir string) postgrestest.Option {
+	return WithGooseFS(nil, dir)
+}
+
+// WithGooseFS returns an option that applies the goose migrations of the directory
+// of fsys, like an embed.FS, to the test database after creating it, setting the base
+// filesystem of goose with goose.SetBaseFS. A nil fsys uses the OS filesystem.
+// The base filesystem is restored to the OS filesystem afterwards.
+func WithGooseFS(fsys fs.FS, dir string) postgrestest.Option {
+	return postgrestest.WithSetupFunction(func(db *sql.DB) error {
+		gooseMu.Lock()
+		defer gooseMu.Unlock()
+		if err := goose.SetDialect("postgres"); err != nil {
+			return err
+		}
+		goose.SetBaseFS(fsys)
+		defer goose.SetBaseFS(nil)
+		if err := goose.Up(db, dir); err != nil {
+			return fmt.Errorf("goose up from %s: %w", dir, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,31 @@
+package postgrestestgoose
+
+import (
+	"embed"
+	"testing"
+
+	"github.com/crossworth/postgrestest"
+	"github.com/stretchr/testify/require"
+)
+
+//go:embed testdata/migrations/*.sql
+var migrations embed.FS
+
+func TestWithGoose(t *testing.T) {
+	t.Parallel()
+	db := postgrestest.NewPostgresTestDB(t, WithGoose("testdata/migrations"))
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM items;`).Scan(&count))
+	require.Equal(t, 2, count)
+}
+
+func TestWithGooseFS(t *testing.T) {
+	t.Parallel()
+	db := postgrestest.NewPostgresTestDB(t, WithGooseFS(migrations, "testdata/migrations"))
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM items;`).Scan(&count))
+	require.Equal(t, 2, count)
+	var version int
+	require.NoError(t, db.QueryRow(`SELECT max(version_id) FROM goose_db_version;`).Scan(&version))
+	require.Equal(t, 2, version)
+}
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE TABLE items (id serial PRIMARY KEY, name text NOT NULL);
+
+-- +goose Down
+DROP TABLE items;
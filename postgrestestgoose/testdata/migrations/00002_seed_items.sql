@@ -0,0 +1,5 @@
+-- +goose Up
+INSERT INTO items (name) VALUES ('a'), ('b');
+
+-- +goose Down
+DELETE FROM items;
@@ -0,0 +1,55 @@
+// Package postgrestestmigrate provides options for running golang-migrate migrations on the
+// test databases of postgrestest, from a source URL like file://migrations.
+package postgrestestmigrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/crossworth/postgrestest"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file" // file:// source
+)
+
+// WithGolangMigrate returns an option that runs golang-migrate up with the migrations of
+// the source URL, like "file://migrations", on the test database after creating it.
+// The file source is registered by the package, other sources must be imported by the
+// caller. When a migration fails the test fails with its version and the error of
+// golang-migrate, which includes the failed statement.
+func WithGolangMigrate(sourceURL string) postgrestest.Option {
+	return postgrestest.WithSetupFunction(func(db *sql.DB) error {
+		return up(db, sourceURL)
+	})
+}
+
+// up runs the migrations of the source URL on the database.
+func up(db *sql.DB, sourceURL string) error {
+	ctx := context.Background()
+	// the driver only closes the connection, the database is closed by postgrestest
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("golang-migrate: %w", err)
+	}
+	m, err := migrate.NewWithDatabaseInstance(sourceURL, "postgres", driver)
+	if err != nil {
+		_ = driver.Close()
+		return fmt.Errorf("golang-migrate: %w", err)
+	}
+	defer m.Close()
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		// the failed migration is left as the dirty version
+		if version, dirty, versionErr := m.Version(); versionErr == nil && dirty {
+			return fmt.Errorf("golang-migrate up from %s failed on version %d: %w", sourceURL, version, err)
+		}
+		return fmt.Errorf("golang-migrate up from %s: %w", sourceURL, err)
+	}
+	return nil
+}
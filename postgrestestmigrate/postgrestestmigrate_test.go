@@ -0,0 +1,58 @@
+package postgrestestmigrate
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/crossworth/postgrestest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithGolangMigrate(t *testing.T) {
+	t.Parallel()
+	db := postgrestest.NewPostgresTestDB(t, WithGolangMigrate("file://testdata/migrations"))
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM items;`).Scan(&count))
+	require.Equal(t, 2, count)
+	var version int
+	require.NoError(t, db.QueryRow(`SELECT version FROM schema_migrations;`).Scan(&version))
+	require.Equal(t, 2, version)
+}
+
+// failingT records the failures of the test instead of failing it.
+type failingT struct {
+	errors   []string
+	cleanups []func()
+}
+
+func (f *failingT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+// FailNow stops the calling goroutine, like testing.T does.
+func (f *failingT) FailNow() {
+	runtime.Goexit()
+}
+
+func (f *failingT) Cleanup(fn func()) {
+	f.cleanups = append(f.cleanups, fn)
+}
+
+func TestWithGolangMigrateFailure(t *testing.T) {
+	t.Parallel()
+	ft := &failingT{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		postgrestest.NewPostgresTest(ft, WithGolangMigrate("file://testdata/broken"))
+	}()
+	<-done
+	for i := len(ft.cleanups) - 1; i >= 0; i-- {
+		ft.cleanups[i]()
+	}
+	failures := strings.Join(ft.errors, "\n")
+	require.Contains(t, failures, "golang-migrate up from file://testdata/broken failed on version 1")
+	require.Contains(t, failures, `relation "missing" does not exist`)
+}
@@ -0,0 +1 @@
+INSERT INTO missing (name) VALUES ('a');
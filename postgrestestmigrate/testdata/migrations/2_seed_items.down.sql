@@ -0,0 +1 @@
+DELETE FROM items;
@@ -0,0 +1 @@
+INSERT INTO items (name) VALUES ('a'), ('b');
@@ -0,0 +1,45 @@
+// Package postgrestestpgx provides helpers for using postgrestest with the native pgx
+// interface, returning pgxpool pools connected to the test databases.
+package postgrestestpgx
+
+import (
+	"context"
+
+	"github.com/crossworth/postgrestest"
+	"github.com/jackc/pgx/v4/pgxpool"
+	pgxpoolv5 "github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+// NewPostgresTestPool creates a test database like postgrestest.NewPostgresTest
+// and returns a *pgxpool.Pool connected to it.
+// The pool is closed before the test database is deleted.
+func NewPostgresTestPool(t postgrestest.TestingT, opts ...postgrestest.Option) *pgxpool.Pool {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	testDB := postgrestest.NewPostgresTest(t, opts...)
+	pool, err := pgxpool.Connect(context.Background(), testDB)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	require.NoError(t, pool.Ping(context.Background()))
+	return pool
+}
+
+// NewPostgresTestPoolV5 is NewPostgresTestPool for pgx/v5, it returns a pgx/v5
+// *pgxpool.Pool connected to the test database.
+func NewPostgresTestPoolV5(t postgrestest.TestingT, opts ...postgrestest.Option) *pgxpoolv5.Pool {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	testDB := postgrestest.NewPostgresTest(t, opts...)
+	pool, err := pgxpoolv5.New(context.Background(), testDB)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	require.NoError(t, pool.Ping(context.Background()))
+	return pool
+}
@@ -0,0 +1,30 @@
+package postgrestestpgx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPostgresTestPool(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	pool := NewPostgresTestPool(t)
+	_, err := pool.Exec(ctx, `CREATE TABLE items (id serial PRIMARY KEY, name text NOT NULL);`)
+	require.NoError(t, err)
+	_, err = pool.Exec(ctx, `INSERT INTO items (name) VALUES ($1), ($2);`, "a", "b")
+	require.NoError(t, err)
+	var count int
+	require.NoError(t, pool.QueryRow(ctx, `SELECT count(*) FROM items;`).Scan(&count))
+	require.Equal(t, 2, count)
+}
+
+func TestNewPostgresTestPoolV5(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	pool := NewPostgresTestPoolV5(t)
+	var name string
+	require.NoError(t, pool.QueryRow(ctx, `SELECT $1::text;`, "a").Scan(&name))
+	require.Equal(t, "a", name)
+}
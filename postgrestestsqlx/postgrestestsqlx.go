@@ -0,0 +1,22 @@
+// Package postgrestestsqlx provides helpers for using postgrestest with sqlx, returning
+// the test database as a *sqlx.DB instead of wrapping the DSN on every test.
+package postgrestestsqlx
+
+import (
+	"github.com/crossworth/postgrestest"
+	"github.com/jmoiron/sqlx"
+)
+
+// NewPostgresTestSQLX creates a test database like postgrestest.NewPostgresTest
+// and returns a *sqlx.DB connected to it with the driver chosen with WithDriverName,
+// like postgrestest.NewPostgresTestDB.
+// The connection is closed before the test database is deleted.
+func NewPostgresTestSQLX(t postgrestest.TestingT, opts ...postgrestest.Option) *sqlx.DB {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	// all the Postgres drivers use the $1 bind vars, sqlx only knows some of their names
+	return sqlx.NewDb(postgrestest.NewPostgresTestDB(t, opts...), "postgres")
+}
@@ -0,0 +1,40 @@
+package postgrestestsqlx
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/crossworth/postgrestest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPostgresTestSQLX(t *testing.T) {
+	t.Parallel()
+	db := NewPostgresTestSQLX(t)
+	_, err := db.Exec(`CREATE TABLE items (id serial PRIMARY KEY, name text NOT NULL);`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO items (name) VALUES ('a'), ('b');`)
+	require.NoError(t, err)
+	type item struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+	var first item
+	err = db.Get(&first, `SELECT id, name FROM items WHERE name = $1;`, "a")
+	require.NoError(t, err)
+	require.Equal(t, item{ID: 1, Name: "a"}, first)
+	var items []item
+	err = db.Select(&items, `SELECT id, name FROM items ORDER BY id;`)
+	require.NoError(t, err)
+	require.Equal(t, []item{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}, items)
+}
+
+func TestNewPostgresTestSQLXDriverName(t *testing.T) {
+	t.Parallel()
+	db := NewPostgresTestSQLX(t, postgrestest.WithDriverName("pgx/v5"))
+	require.Contains(t, reflect.TypeOf(db.Driver()).Elem().PkgPath(), "pgx/v5")
+	var name string
+	require.NoError(t, db.Get(&name, `SELECT $1::text;`, "a"))
+	require.Equal(t, "a", name)
+}
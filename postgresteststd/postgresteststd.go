@@ -0,0 +1,62 @@
+// Package postgresteststd provides the postgrestest helpers typed for the standard
+// testing package, like NewPostgresTest taking a *testing.T, so the common case shows
+// up in the documentation and editor completions. The postgrestest functions accept
+// any TestingT, like mocks or other test frameworks, and don't import testing.
+package postgresteststd
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/crossworth/postgrestest"
+)
+
+// NewPostgresTest is postgrestest.NewPostgresTest for the common case of a *testing.T.
+func NewPostgresTest(t *testing.T, opts ...postgrestest.Option) string {
+	t.Helper()
+	return postgrestest.NewPostgresTest(t, opts...)
+}
+
+// NewPostgresTestWithCleanup is postgrestest.NewPostgresTestWithCleanup for the common
+// case of a *testing.T.
+func NewPostgresTestWithCleanup(t *testing.T, opts ...postgrestest.Option) (string, func()) {
+	t.Helper()
+	return postgrestest.NewPostgresTestWithCleanup(t, opts...)
+}
+
+// NewPostgresTestBench is postgrestest.NewPostgresTestBench for the common case of a *testing.B.
+func NewPostgresTestBench(b *testing.B, opts ...postgrestest.Option) (*sql.DB, func()) {
+	b.Helper()
+	return postgrestest.NewPostgresTestBench(b, opts...)
+}
+
+// RunOnVersions runs fn as a subtest for each Postgres version, named after it, with
+// a test database on a server of that version provisioned with WithDockerProvisioner
+// and WithPostgresVersion, so the compatibility bugs across versions are caught in one
+// place:
+//
+//	func TestUpsert(t *testing.T) {
+//		postgresteststd.RunOnVersions(t, []string{"13", "14", "15", "16"}, func(t *testing.T, dsn string) {
+//			db := postgrestest.Open(t, dsn)
+//			// test code
+//		})
+//	}
+//
+// The options are used to create each test database. The servers are started the
+// first time a version is used and shared by the tests of the process, the subtests
+// run one after the other.
+func RunOnVersions(t *testing.T, versions []string, fn func(t *testing.T, dsn string), opts ...postgrestest.Option) {
+	t.Helper()
+	if len(versions) == 0 {
+		t.Fatal("RunOnVersions requires at least one Postgres version")
+	}
+	for _, version := range versions {
+		version := version
+		t.Run(version, func(t *testing.T) {
+			t.Helper()
+			dsn := postgrestest.NewPostgresTest(t, append(append([]postgrestest.Option{}, opts...),
+				postgrestest.WithDockerProvisioner(), postgrestest.WithPostgresVersion(version))...)
+			fn(t, dsn)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package postgresteststd
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/crossworth/postgrestest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPostgresTest(t *testing.T) {
+	t.Parallel()
+	db := postgrestest.Open(t, NewPostgresTest(t))
+	_, err := db.Exec(`CREATE TABLE items (id serial PRIMARY KEY);`)
+	require.NoError(t, err)
+}
+
+func TestRunOnVersions(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("docker is not installed")
+	}
+	var versions []string
+	RunOnVersions(t, []string{"13", "16"}, func(t *testing.T, dsn string) {
+		db := postgrestest.Open(t, dsn)
+		var version string
+		require.NoError(t, db.QueryRow(`SHOW server_version;`).Scan(&version))
+		versions = append(versions, version)
+	})
+	require.Len(t, versions, 2)
+	require.Regexp(t, `^13\.`, versions[0])
+	require.Regexp(t, `^16\.`, versions[1])
+}
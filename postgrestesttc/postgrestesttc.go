@@ -0,0 +1,99 @@
+// Package postgrestesttc provides the base server for postgrestest with testcontainers-go,
+// a container started once per test binary and removed by ryuk when it exits.
+package postgrestesttc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/crossworth/postgrestest"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// Image is the image of the container started by WithTestcontainers.
+const Image = "postgres:14"
+
+// servers holds the addresses of the containers by image, started on first use.
+var servers struct {
+	sync.Mutex
+	addresses map[string]string
+}
+
+// WithTestcontainers returns an option that uses a Postgres container, started with
+// testcontainers-go, as the base server. The container is started once per test binary,
+// the first time the option is created, and shared by all of its tests. It's removed
+// by the testcontainers reaper (ryuk) when the test binary exits, including when it's
+// killed, so no stray containers are left behind.
+// Creating the option fails the test when the container can't be started.
+func WithTestcontainers(t postgrestest.TestingT) postgrestest.Option {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	address, err := baseAddress(Image)
+	require.NoError(t, err)
+	return postgrestest.WithFixedBaseAddress(address)
+}
+
+// WithTestcontainersVersion is like WithTestcontainers with the version of the server,
+// a major version like "15" or an exact one like "15.3", used as the tag of the
+// official postgres image. Each version has its own container.
+func WithTestcontainersVersion(t postgrestest.TestingT, version string) postgrestest.Option {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	require.NoError(t, postgrestest.ValidatePostgresVersion(version))
+	address, err := baseAddress("postgres:" + version)
+	require.NoError(t, err)
+	return postgrestest.WithFixedBaseAddress(address)
+}
+
+// baseAddress returns the base address of the shared container of the image, starting
+// it when needed. Failures aren't remembered, the next test tries again.
+func baseAddress(image string) (string, error) {
+	servers.Lock()
+	defer servers.Unlock()
+	if address, ok := servers.addresses[image]; ok {
+		return address, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        image,
+			ExposedPorts: []string{"5432/tcp"},
+			Env:          map[string]string{"POSTGRES_PASSWORD": "root"},
+			// we disable a few options to make the database faster for testing
+			Cmd: []string{"postgres", "-c", "fsync=off", "-c", "synchronous_commit=off",
+				"-c", "full_page_writes=off", "-c", "max_connections=500"},
+			// the server is restarted after running the initialization scripts
+			WaitingFor: wait.ForLog("database system is ready to accept connections").WithOccurrence(2),
+		},
+		Started: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to start the Postgres container: %w", err)
+	}
+	host, err := container.Host(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the Postgres container host: %w", err)
+	}
+	port, err := container.MappedPort(ctx, "5432/tcp")
+	if err != nil {
+		return "", fmt.Errorf("failed to get the Postgres container port: %w", err)
+	}
+	address := "postgres://postgres:root@" + net.JoinHostPort(host, port.Port())
+	if servers.addresses == nil {
+		servers.addresses = make(map[string]string)
+	}
+	servers.addresses[image] = address
+	return address, nil
+}
@@ -0,0 +1,87 @@
+package postgrestest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithQueryLogging is an option that logs the statements executed on the connection
+// returned by NewPostgresTestDB with the test, with their duration and error, so the
+// queries leading to a failure show up in its output. The connections opened from the
+// DSN, like with Open, aren't logged.
+func WithQueryLogging() Option {
+	return func(opts *options) {
+		opts.queryLogging = true
+	}
+}
+
+// WithSlowQueryThreshold is an option that records the statements executed on the
+// connection returned by NewPostgresTestDB taking d or longer, and logs a report of
+// them with the test when it finishes, the slowest first, so performance regressions,
+// like a missing index, are noticed in the integration tests. Like the other logs, the
+// report is only printed for the failed tests unless the tests run with -v.
+func WithSlowQueryThreshold(d time.Duration) Option {
+	return func(opts *options) {
+		opts.slowQueryThreshold = d
+	}
+}
+
+// slowQuery is a statement that took longer than the slow query threshold.
+type slowQuery struct {
+	query    string
+	duration time.Duration
+}
+
+// queryHook returns the hook logging the statements with the test and recording the
+// slow ones, nil when neither is enabled.
+func (o *options) queryHook(t TestingT) statementHook {
+	if !o.queryLogging && o.slowQueryThreshold <= 0 {
+		return nil
+	}
+	var mu sync.Mutex
+	var slowQueries []slowQuery
+	if o.slowQueryThreshold > 0 {
+		t.Cleanup(func() {
+			mu.Lock()
+			defer mu.Unlock()
+			if report := slowQueryReport(slowQueries, o.slowQueryThreshold); report != "" {
+				logf(t, "%s", report)
+			}
+		})
+	}
+	return func(query string, duration time.Duration, err error) {
+		if o.queryLogging {
+			if err != nil {
+				logf(t, "postgrestest: query failed after %s: %s: %v", duration, query, err)
+			} else {
+				logf(t, "postgrestest: query took %s: %s", duration, query)
+			}
+		}
+		if o.slowQueryThreshold > 0 && duration >= o.slowQueryThreshold {
+			mu.Lock()
+			defer mu.Unlock()
+			slowQueries = append(slowQueries, slowQuery{query: query, duration: duration})
+		}
+	}
+}
+
+// slowQueryReport returns the report of the slow queries, the slowest first, empty
+// when there are none.
+func slowQueryReport(slowQueries []slowQuery, threshold time.Duration) string {
+	if len(slowQueries) == 0 {
+		return ""
+	}
+	sorted := append([]slowQuery(nil), slowQueries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].duration > sorted[j].duration
+	})
+	var b strings.Builder
+	fmt.Fprintf(&b, "postgrestest: %d statements took %s or longer:", len(sorted), threshold)
+	for _, q := range sorted {
+		fmt.Fprintf(&b, "\n\t%s: %s", q.duration, q.query)
+	}
+	return b.String()
+}
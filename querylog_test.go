@@ -0,0 +1,95 @@
+package postgrestest
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithQueryLogging(t *testing.T) {
+	t.Parallel()
+	ft := &fakeT{}
+	var db *sql.DB
+	ft.run(func() {
+		db = NewPostgresTestDB(ft, WithEngine(EngineSQLite), WithDriverName("sqlite"), WithQueryLogging())
+	})
+	require.Empty(t, ft.failures())
+	_, err := db.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY);`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO missing VALUES (1);`)
+	require.Error(t, err)
+	require.Regexp(t, `postgrestest: query took .+: CREATE TABLE items \(id INTEGER PRIMARY KEY\);`, ft.logged())
+	require.Regexp(t, `postgrestest: query failed after .+: INSERT INTO missing VALUES \(1\);: .*no such table: missing`, ft.logged())
+	ft.runCleanups()
+	require.Empty(t, ft.failures())
+}
+
+func TestWithQueryLoggingClock(t *testing.T) {
+	t.Parallel()
+	// every reading of the clock advances it by 5ms
+	var mu sync.Mutex
+	now := time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(5 * time.Millisecond)
+		return now
+	}
+	ft := &fakeT{}
+	var db *sql.DB
+	ft.run(func() {
+		db = NewPostgresTestDB(ft, WithEngine(EngineSQLite), WithDriverName("sqlite"), WithClock(clock),
+			WithQueryLogging(), WithSlowQueryThreshold(5*time.Millisecond))
+	})
+	require.Empty(t, ft.failures())
+	_, err := db.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY);`)
+	require.NoError(t, err)
+	require.Contains(t, ft.logged(), "postgrestest: query took 5ms: CREATE TABLE items (id INTEGER PRIMARY KEY);")
+	ft.runCleanups()
+	require.Empty(t, ft.failures())
+	require.Contains(t, ft.logged(), "\n\t5ms: CREATE TABLE items (id INTEGER PRIMARY KEY);")
+}
+
+func TestWithoutQueryLogging(t *testing.T) {
+	t.Parallel()
+	ft := &fakeT{}
+	var db *sql.DB
+	ft.run(func() {
+		db = NewPostgresTestDB(ft, WithEngine(EngineSQLite), WithDriverName("sqlite"))
+	})
+	require.Empty(t, ft.failures())
+	_, err := db.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY);`)
+	require.NoError(t, err)
+	require.NotContains(t, ft.logged(), "CREATE TABLE")
+	ft.runCleanups()
+}
+
+func TestWithSlowQueryThreshold(t *testing.T) {
+	t.Parallel()
+	ft := &fakeT{}
+	var db *sql.DB
+	ft.run(func() {
+		// every statement takes at least a nanosecond
+		db = NewPostgresTestDB(ft, WithEngine(EngineSQLite), WithDriverName("sqlite"), WithSlowQueryThreshold(time.Nanosecond))
+	})
+	require.Empty(t, ft.failures())
+	_, err := db.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY);`)
+	require.NoError(t, err)
+	require.NotContains(t, ft.logged(), "CREATE TABLE")
+	ft.runCleanups()
+	require.Empty(t, ft.failures())
+	require.Regexp(t, `postgrestest: [0-9]+ statements took 1ns or longer:(\n\t.+)*\n\t.+: CREATE TABLE items`, ft.logged())
+}
+
+func TestSlowQueryReport(t *testing.T) {
+	t.Parallel()
+	require.Empty(t, slowQueryReport(nil, time.Second))
+	report := slowQueryReport([]slowQuery{
+		{query: "SELECT 1;", duration: 2 * time.Second},
+		{query: "SELECT 2;", duration: 5 * time.Second},
+	}, time.Second)
+	require.Equal(t, "postgrestest: 2 statements took 1s or longer:\n\t5s: SELECT 2;\n\t2s: SELECT 1;", report)
+}
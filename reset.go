@@ -0,0 +1,131 @@
+package postgrestest
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/stretchr/testify/require"
+)
+
+// NewPostgresTestBench creates a test database like NewPostgresTest and returns
+// an opened connection to it, together with a function that truncates all the tables.
+// It's meant to be used with benchmarks, where creating a database per iteration
+// would dominate the measurement, the database is created once and reset between
+// iterations instead:
+//
+//	db, reset := postgrestest.NewPostgresTestBench(b)
+//	for i := 0; i < b.N; i++ {
+//		b.StopTimer()
+//		reset()
+//		b.StartTimer()
+//		// code being measured
+//	}
+//
+// The connection is closed before the database is deleted.
+func NewPostgresTestBench(b TestingT, opts ...Option) (*sql.DB, func()) {
+	if h, ok := b.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	db := Open(b, NewPostgresTest(b, opts...))
+	return db, func() {
+		require.NoError(b, truncateTables(db, nil))
+	}
+}
+
+// TruncateAllTables truncates all the user tables of the database, discovered from
+// pg_catalog, with RESTART IDENTITY CASCADE. It allows reusing one database across
+// many subtests, which is much faster than creating one for each:
+//
+//	db := postgrestest.Open(t, postgrestest.NewPostgresTest(t))
+//	for _, tc := range testCases {
+//		t.Run(tc.name, func(t *testing.T) {
+//			postgrestest.TruncateAllTables(t, db, postgrestest.WithExcludeTables("countries"))
+//			// test code
+//		})
+//	}
+//
+// The tables of extensions, like PostGIS spatial_ref_sys, are never truncated.
+func TruncateAllTables(t TestingT, db *sql.DB, opts ...TruncateOption) {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	o := &truncateOptions{exclude: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	require.NoError(t, truncateTables(db, o.exclude))
+}
+
+// TruncateOption is an option for TruncateAllTables.
+type TruncateOption func(opts *truncateOptions)
+
+// truncateOptions holds the options of TruncateAllTables.
+type truncateOptions struct {
+	exclude map[string]bool
+}
+
+// WithExcludeTables is an option that keeps the tables, allowing reusing a database
+// between tests while keeping the reference data, like countries or currencies, seeded
+// once. The tables can be schema qualified, like "billing.currencies", unqualified
+// names are kept on all the schemas.
+// Since it truncates with CASCADE, kept tables referencing a truncated table with
+// a foreign key are truncated too.
+func WithExcludeTables(tables ...string) TruncateOption {
+	return func(opts *truncateOptions) {
+		for _, table := range tables {
+			opts.exclude[table] = true
+		}
+	}
+}
+
+// TruncateAllTablesExcept truncates all the user tables of the database, except
+// for the ones on the keep list, like TruncateAllTables with WithExcludeTables.
+func TruncateAllTablesExcept(t TestingT, db *sql.DB, keep ...string) {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	TruncateAllTables(t, db, WithExcludeTables(keep...))
+}
+
+// truncateTables truncates all the user tables of the database, restarting
+// their identities, except for the tables on the keep list.
+func truncateTables(db *sql.DB, keep map[string]bool) error {
+	rows, err := db.Query(`SELECT n.nspname, c.relname, quote_ident(n.nspname) || '.' || quote_ident(c.relname)
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('r', 'p')
+  AND NOT c.relispartition
+  AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+  AND n.nspname NOT LIKE 'pg_toast%'
+  AND n.nspname NOT LIKE 'pg_temp%'
+  AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.objid = c.oid AND d.deptype = 'e');`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var schema, table, qualified string
+		if err := rows.Scan(&schema, &table, &qualified); err != nil {
+			return err
+		}
+		if keep[table] || keep[schema+"."+table] {
+			continue
+		}
+		tables = append(tables, qualified)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+	_, err = db.Exec(`TRUNCATE TABLE ` + strings.Join(tables, ", ") + ` RESTART IDENTITY CASCADE;`)
+	return err
+}
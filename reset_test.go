@@ -0,0 +1,81 @@
+package postgrestest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPostgresTestBench(t *testing.T) {
+	t.Parallel()
+	db, reset := NewPostgresTestBench(t)
+	_, err := db.Exec(`CREATE TABLE items (id serial PRIMARY KEY, name text NOT NULL);`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO items (name) VALUES ('a'), ('b');`)
+	require.NoError(t, err)
+	reset()
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM items;`).Scan(&count))
+	require.Equal(t, 0, count)
+	var id int
+	require.NoError(t, db.QueryRow(`INSERT INTO items (name) VALUES ('c') RETURNING id;`).Scan(&id))
+	require.Equal(t, 1, id)
+}
+
+func BenchmarkNewPostgresTestBench(b *testing.B) {
+	db, reset := NewPostgresTestBench(b)
+	_, err := db.Exec(`CREATE TABLE items (id serial PRIMARY KEY, name text NOT NULL);`)
+	require.NoError(b, err)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		reset()
+		b.StartTimer()
+		_, err := db.Exec(`INSERT INTO items (name) VALUES ('a'), ('b'), ('c');`)
+		require.NoError(b, err)
+	}
+}
+
+func TestTruncateAllTablesExcept(t *testing.T) {
+	t.Parallel()
+	db := Open(t, NewPostgresTest(t))
+	_, err := db.Exec(`CREATE TABLE countries (code text PRIMARY KEY);
+CREATE TABLE "Orders" (id serial PRIMARY KEY, country text NOT NULL REFERENCES countries (code));
+INSERT INTO countries (code) VALUES ('BR'), ('US');
+INSERT INTO "Orders" (country) VALUES ('BR'), ('US');`)
+	require.NoError(t, err)
+	TruncateAllTablesExcept(t, db, "public.countries")
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM countries;`).Scan(&count))
+	require.Equal(t, 2, count)
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM "Orders";`).Scan(&count))
+	require.Equal(t, 0, count)
+	var id int
+	require.NoError(t, db.QueryRow(`INSERT INTO "Orders" (country) VALUES ('BR') RETURNING id;`).Scan(&id))
+	require.Equal(t, 1, id)
+}
+
+func TestTruncateAllTables(t *testing.T) {
+	t.Parallel()
+	db := Open(t, NewPostgresTest(t))
+	_, err := db.Exec(`CREATE SCHEMA billing;
+CREATE TABLE billing.currencies (code text PRIMARY KEY);
+CREATE TABLE currencies (code text PRIMARY KEY);
+CREATE TABLE items (id serial PRIMARY KEY, name text NOT NULL);
+INSERT INTO billing.currencies (code) VALUES ('BRL');
+INSERT INTO currencies (code) VALUES ('USD');
+INSERT INTO items (name) VALUES ('a'), ('b');`)
+	require.NoError(t, err)
+	TruncateAllTables(t, db, WithExcludeTables("billing.currencies"))
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM billing.currencies;`).Scan(&count))
+	require.Equal(t, 1, count)
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM currencies;`).Scan(&count))
+	require.Equal(t, 0, count)
+	TruncateAllTables(t, db)
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM billing.currencies;`).Scan(&count))
+	require.Equal(t, 0, count)
+	var id int
+	require.NoError(t, db.QueryRow(`INSERT INTO items (name) VALUES ('c') RETURNING id;`).Scan(&id))
+	require.Equal(t, 1, id)
+}
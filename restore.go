@@ -0,0 +1,62 @@
+package postgrestest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// WithRestoreFrom is an option that restores the dump, in the custom or directory
+// format of pg_dump, on the test database after creating it, before running the
+// migrations and seeds. It allows reproducing bugs with production like data.
+// The restore runs the pg_restore command, which must be on the PATH, with
+// --no-owner, so the objects are owned by the role connecting to the test database.
+func WithRestoreFrom(dumpPath string) Option {
+	return func(opts *options) {
+		opts.restoreFrom = dumpPath
+	}
+}
+
+// restoreDatabase restores the dump on the test database with pg_restore.
+func restoreDatabase(ctx context.Context, opts *options, databaseName string) error {
+	if _, err := os.Stat(opts.restoreFrom); err != nil {
+		return fmt.Errorf("failed to restore the dump: %w", err)
+	}
+	pgRestore, err := exec.LookPath("pg_restore")
+	if err != nil {
+		return fmt.Errorf("failed to restore the dump, pg_restore must be installed: %w", err)
+	}
+	dsn, err := opts.setupDSN(databaseName)
+	if err != nil {
+		return err
+	}
+	dbname, env := commandDSN(dsn)
+	cmd := exec.CommandContext(ctx, pgRestore, "--no-owner", "--exit-on-error", "--dbname", dbname, opts.restoreFrom)
+	cmd.Env = env
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to restore the dump: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// commandDSN returns the DSN to pass to the Postgres client commands, without the
+// password, and their environment, with the password on PGPASSWORD, since the
+// arguments are visible to other users. DSNs that aren't URLs are kept as they are.
+func commandDSN(dsn string) (string, []string) {
+	env := os.Environ()
+	u, err := url.Parse(dsn)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") || u.User == nil {
+		return dsn, env
+	}
+	if password, ok := u.User.Password(); ok {
+		env = append(env, "PGPASSWORD="+password)
+		u.User = url.User(u.User.Username())
+	}
+	return u.String(), env
+}
@@ -0,0 +1,134 @@
+package postgrestest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// outerSavepoint is the savepoint created when the transaction wrapping the test starts.
+const outerSavepoint = "postgrestest_outer"
+
+// NewPostgresTestSavepoint creates a test database like NewPostgresTest and returns a
+// connection to it running inside a transaction. The transactions started with
+// BeginTx on the connection are translated to savepoints, so code managing its
+// own transactions can commit them, and on cleanup everything is rolled back,
+// leaving the database as it was created.
+//
+// The transaction wrapping the test has some limitations:
+//   - statements that can't run inside a transaction block, like VACUUM or
+//     CREATE INDEX CONCURRENTLY, fail;
+//   - BEGIN, COMMIT and ROLLBACK statements are not translated, COMMIT commits
+//     the wrapping transaction and must not be used;
+//   - a failed statement outside of a transaction started with BeginTx aborts
+//     the wrapping transaction, failing the following statements;
+//   - the isolation level and read only options of BeginTx are ignored;
+//   - sequences advanced by the test are not rolled back, like on any transaction.
+func NewPostgresTestSavepoint(t TestingT, opts ...Option) *sql.Conn {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	o, dsn, _ := newPostgresTest(context.Background(), t, opts)
+	driverDB, err := sql.Open(o.driverName, dsn)
+	require.NoError(t, err)
+	d := driverDB.Driver()
+	_ = driverDB.Close()
+	db := sql.OpenDB(&savepointConnector{
+		hookedConnector: hookedConnector{driver: d, dsn: dsn, hook: func(string, time.Duration, error) {}},
+	})
+	// the wrapping transaction lives on the connection, it can't be shared
+	db.SetMaxOpenConns(1)
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		_ = db.Close()
+		require.NoError(t, err)
+	}
+	t.Cleanup(func() {
+		defer db.Close()
+		defer conn.Close()
+		err := conn.Raw(func(driverConn interface{}) error {
+			return driverConn.(*savepointConn).rollback()
+		})
+		require.NoError(t, err)
+	})
+	return conn
+}
+
+// savepointConnector is a driver.Connector returning connections that run inside
+// a transaction, translating the transactions started on them to savepoints.
+type savepointConnector struct {
+	hookedConnector
+}
+
+func (c *savepointConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.hookedConnector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	savepointConn := &savepointConn{hookedConn: conn.(*hookedConn)}
+	for _, statement := range []string{`BEGIN;`, `SAVEPOINT ` + outerSavepoint + `;`} {
+		if _, err := savepointConn.ExecContext(ctx, statement, nil); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return savepointConn, nil
+}
+
+// savepointConn is a connection inside a transaction, where transactions are savepoints.
+type savepointConn struct {
+	*hookedConn
+	// depth is the number of savepoints started by BeginTx, used to name them.
+	depth int
+}
+
+func (c *savepointConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *savepointConn) BeginTx(ctx context.Context, _ driver.TxOptions) (driver.Tx, error) {
+	name := fmt.Sprintf("postgrestest_savepoint_%d", c.depth+1)
+	if _, err := c.ExecContext(ctx, `SAVEPOINT `+name+`;`, nil); err != nil {
+		return nil, err
+	}
+	c.depth++
+	return &savepointTx{conn: c, name: name}, nil
+}
+
+// rollback rolls back the transaction wrapping the connection.
+func (c *savepointConn) rollback() error {
+	ctx := context.Background()
+	if _, err := c.ExecContext(ctx, `ROLLBACK TO SAVEPOINT `+outerSavepoint+`;`, nil); err != nil {
+		return err
+	}
+	_, err := c.ExecContext(ctx, `ROLLBACK;`, nil)
+	return err
+}
+
+// savepointTx is a transaction implemented with a savepoint.
+type savepointTx struct {
+	conn *savepointConn
+	name string
+}
+
+func (tx *savepointTx) Commit() error {
+	tx.conn.depth--
+	_, err := tx.conn.ExecContext(context.Background(), `RELEASE SAVEPOINT `+tx.name+`;`, nil)
+	return err
+}
+
+func (tx *savepointTx) Rollback() error {
+	tx.conn.depth--
+	ctx := context.Background()
+	if _, err := tx.conn.ExecContext(ctx, `ROLLBACK TO SAVEPOINT `+tx.name+`;`, nil); err != nil {
+		return err
+	}
+	_, err := tx.conn.ExecContext(ctx, `RELEASE SAVEPOINT `+tx.name+`;`, nil)
+	return err
+}
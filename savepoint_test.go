@@ -0,0 +1,50 @@
+package postgrestest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPostgresTestSavepoint(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	ft := &fakeT{}
+	var conn *sql.Conn
+	ft.run(func() {
+		// the database is kept to check it was rolled back
+		conn = NewPostgresTestSavepoint(ft, WithDeleteDatabaseFunction(nil))
+	})
+	require.Empty(t, ft.failures())
+	var databaseName string
+	require.NoError(t, conn.QueryRowContext(ctx, `SELECT current_database();`).Scan(&databaseName))
+	_, err := conn.ExecContext(ctx, `CREATE TABLE items (id serial PRIMARY KEY, name text NOT NULL);`)
+	require.NoError(t, err)
+	// the code under test commits its own transaction
+	tx, err := conn.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = tx.ExecContext(ctx, `INSERT INTO items (name) VALUES ('committed');`)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	tx, err = conn.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = tx.ExecContext(ctx, `INSERT INTO items (name) VALUES ('rolled back');`)
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+	var count int
+	require.NoError(t, conn.QueryRowContext(ctx, `SELECT count(*) FROM items;`).Scan(&count))
+	require.Equal(t, 1, count)
+	ft.runCleanups()
+	require.Empty(t, ft.failures())
+	dsn, err := BuildDSN(testBaseAddress(), databaseName, nil)
+	require.NoError(t, err)
+	db := Open(t, dsn)
+	var table sql.NullString
+	require.NoError(t, db.QueryRow(`SELECT to_regclass('items')::text;`).Scan(&table))
+	require.False(t, table.Valid, "the committed transaction was not rolled back")
+	require.NoError(t, db.Close())
+	baseDB := Open(t, testBaseAddress())
+	require.NoError(t, ForceDeleteDatabaseFunction(baseDB, databaseName))
+}
@@ -0,0 +1,118 @@
+package postgrestest
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+// schemaQueries return one row per schema object, with the description used to compare them.
+var schemaQueries = []string{
+	`SELECT CASE c.relkind
+		WHEN 'v' THEN 'view'
+		WHEN 'm' THEN 'materialized view'
+		WHEN 'f' THEN 'foreign table'
+		ELSE 'table'
+	END || ' ' || quote_ident(n.nspname) || '.' || quote_ident(c.relname)
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f')
+  AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+  AND n.nspname NOT LIKE 'pg_toast%'
+  AND n.nspname NOT LIKE 'pg_temp%';`,
+	`SELECT 'column ' || quote_ident(n.nspname) || '.' || quote_ident(c.relname) || '.' || quote_ident(a.attname) || ' ' ||
+	format_type(a.atttypid, a.atttypmod) ||
+	CASE WHEN a.attnotnull THEN ' NOT NULL' ELSE '' END ||
+	COALESCE(' DEFAULT ' || pg_get_expr(d.adbin, d.adrelid), '')
+FROM pg_attribute a
+JOIN pg_class c ON c.oid = a.attrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+WHERE a.attnum > 0
+  AND NOT a.attisdropped
+  AND c.relkind IN ('r', 'p', 'v', 'm', 'f')
+  AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+  AND n.nspname NOT LIKE 'pg_toast%'
+  AND n.nspname NOT LIKE 'pg_temp%';`,
+	`SELECT 'index ' || quote_ident(schemaname) || '.' || quote_ident(indexname) || ': ' || indexdef
+FROM pg_indexes
+WHERE schemaname NOT IN ('pg_catalog', 'information_schema')
+  AND schemaname NOT LIKE 'pg_toast%'
+  AND schemaname NOT LIKE 'pg_temp%';`,
+	`SELECT 'constraint ' || quote_ident(n.nspname) || '.' || quote_ident(c.relname) || '.' || quote_ident(con.conname) || ': ' ||
+	pg_get_constraintdef(con.oid)
+FROM pg_constraint con
+JOIN pg_class c ON c.oid = con.conrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+  AND n.nspname NOT LIKE 'pg_toast%'
+  AND n.nspname NOT LIKE 'pg_temp%';`,
+}
+
+// DiffSchemas compares the schemas of the databases of the DSNs, returning the
+// tables, columns, indexes and constraints that exist only on one of them.
+// Each difference is prefixed with the DSN it exists on, "-" for dsnA and "+" for dsnB,
+// like "+ column public.users.email text NOT NULL". No differences means the
+// schemas are identical. It can be used to detect drift between a schema built
+// by migrations and one loaded from a dump.
+func DiffSchemas(dsnA, dsnB string) ([]string, error) {
+	objectsA, err := schemaObjects(dsnA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the schema of the first database: %w", err)
+	}
+	objectsB, err := schemaObjects(dsnB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the schema of the second database: %w", err)
+	}
+	var differences []string
+	for object := range objectsA {
+		if !objectsB[object] {
+			differences = append(differences, "- "+object)
+		}
+	}
+	for object := range objectsB {
+		if !objectsA[object] {
+			differences = append(differences, "+ "+object)
+		}
+	}
+	sort.Slice(differences, func(i, j int) bool {
+		// sort by object, keeping the changes to the same object together
+		if differences[i][2:] != differences[j][2:] {
+			return differences[i][2:] < differences[j][2:]
+		}
+		return differences[i] < differences[j]
+	})
+	return differences, nil
+}
+
+// schemaObjects returns the descriptions of the schema objects of the database.
+func schemaObjects(dsn string) (map[string]bool, error) {
+	db, err := sql.Open(driverNameForDSN(dsn), dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	objects := make(map[string]bool)
+	for _, query := range schemaQueries {
+		rows, err := db.Query(query)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var object string
+			if err := rows.Scan(&object); err != nil {
+				_ = rows.Close()
+				return nil, err
+			}
+			objects[object] = true
+		}
+		if err := rows.Err(); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return objects, nil
+}
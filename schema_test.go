@@ -0,0 +1,29 @@
+package postgrestest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSchemas(t *testing.T) {
+	t.Parallel()
+	schema := `CREATE TABLE users (id serial PRIMARY KEY, name text NOT NULL);
+CREATE INDEX users_name_idx ON users (name);`
+	testDBA := NewPostgresTest(t)
+	testDBB := NewPostgresTest(t)
+	for _, testDB := range []string{testDBA, testDBB} {
+		_, err := Open(t, testDB).Exec(schema)
+		require.NoError(t, err)
+	}
+	differences, err := DiffSchemas(testDBA, testDBB)
+	require.NoError(t, err)
+	require.Empty(t, differences)
+	_, err = Open(t, testDBB).Exec(`ALTER TABLE users ADD COLUMN email varchar(255) NOT NULL DEFAULT '';`)
+	require.NoError(t, err)
+	differences, err = DiffSchemas(testDBA, testDBB)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`+ column public.users.email character varying(255) NOT NULL DEFAULT ''::character varying`,
+	}, differences)
+}
@@ -0,0 +1,78 @@
+package postgrestest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/stretchr/testify/require"
+)
+
+// SnapshotID identifies a snapshot taken with Snapshot.
+type SnapshotID string
+
+// Snapshot dumps the database of the DSN, like the one returned by NewPostgresTest,
+// so it can be restored later with Restore. It allows taking a known good state,
+// like after running the migrations and loading the fixtures, and restoring it
+// between subtests without running them again:
+//
+//	snapshot := postgrestest.Snapshot(t, dsn)
+//	t.Run("delete", func(t *testing.T) {
+//		defer postgrestest.Restore(t, dsn, snapshot)
+//		// ...
+//	})
+//
+// The snapshot is taken with the pg_dump command, which must be on the PATH, and
+// deleted when the test finishes.
+func Snapshot(t TestingT, dsn string) SnapshotID {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	pgDump, err := exec.LookPath("pg_dump")
+	require.NoError(t, err, "taking a snapshot requires pg_dump")
+	dir, err := os.MkdirTemp("", "postgrestest-snapshot-")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.RemoveAll(dir)
+	})
+	dumpPath := filepath.Join(dir, "snapshot.dump")
+	dbname, env := commandDSN(dsn)
+	require.NoError(t, runPostgresCommand(env, pgDump, "--format", "custom", "--no-owner", "--file", dumpPath, dbname))
+	return SnapshotID(dumpPath)
+}
+
+// Restore restores the snapshot taken with Snapshot on the database of the DSN, with
+// the pg_restore command, which must be on the PATH. The objects of the snapshot are
+// dropped and recreated in a single transaction, so the open connections can stay open,
+// but the objects created after the snapshot, like new tables, are kept.
+func Restore(t TestingT, dsn string, id SnapshotID) {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	pgRestore, err := exec.LookPath("pg_restore")
+	require.NoError(t, err, "restoring a snapshot requires pg_restore")
+	_, err = os.Stat(string(id))
+	require.NoError(t, err, "the snapshot doesn't exist, it's deleted when the test that took it finishes")
+	dbname, env := commandDSN(dsn)
+	require.NoError(t, runPostgresCommand(env, pgRestore, "--clean", "--if-exists", "--no-owner",
+		"--single-transaction", "--exit-on-error", "--dbname", dbname, string(id)))
+}
+
+// runPostgresCommand runs the Postgres client command, returning its stderr on failure.
+func runPostgresCommand(env []string, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Env = env
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w: %s", filepath.Base(name), err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package postgrestest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// createdAtPrefix starts the line of the test database comment holding its creation time.
+const createdAtPrefix = "postgrestest: created at "
+
+// createdAtComment returns the comment line recording the creation time of the test database.
+func createdAtComment(now time.Time) string {
+	return createdAtPrefix + now.UTC().Format(time.RFC3339)
+}
+
+// parseCreatedAt returns the creation time recorded on the test database comment.
+func parseCreatedAt(comment string) (time.Time, bool) {
+	i := strings.LastIndex(comment, createdAtPrefix)
+	if i < 0 {
+		return time.Time{}, false
+	}
+	createdAt, err := time.Parse(time.RFC3339, comment[i+len(createdAtPrefix):])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return createdAt, true
+}
+
+// CleanupStaleDatabases drops the test databases on the base server created more
+// than olderThan ago, returning their names. Test databases are leaked when the
+// test binary crashes or is killed before the cleanup runs, on shared servers they
+// pile up forever. It's meant to be called from TestMain, or from a scheduled job:
+//
+//	func TestMain(m *testing.M) {
+//		if _, err := postgrestest.CleanupStaleDatabases(context.Background(), "", 24*time.Hour); err != nil {
+//			log.Print(err)
+//		}
+//		os.Exit(m.Run())
+//	}
+//
+// An empty base address uses the default base server, see NewPostgresTest. Only the
+// databases with the database prefix, testing_db_ unless changed with WithDatabasePrefix,
+// and the creation time recorded on their comment, which requires the default create
+// function, are dropped, with the delete function of the options. The databases of
+// this process that weren't deleted yet are kept.
+func CleanupStaleDatabases(ctx context.Context, baseAddress string, olderThan time.Duration, opts ...Option) ([]string, error) {
+	if baseAddress != "" {
+		opts = append(append([]Option{}, opts...), WithFixedBaseAddress(baseAddress))
+	}
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	db, closeDB, err := o.openBaseDB()
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+	databases, err := listTestDatabases(ctx, o, db)
+	if err != nil {
+		return nil, err
+	}
+	active := make(map[string]bool)
+	for _, name := range ActiveTestDatabases() {
+		active[name] = true
+	}
+	threshold := o.now().Add(-olderThan)
+	var stale []TestDatabase
+	for _, database := range databases {
+		if database.CreatedAt.IsZero() || !database.CreatedAt.Before(threshold) || active[database.Name] {
+			continue
+		}
+		stale = append(stale, database)
+	}
+	var dropped []string
+	for _, database := range stale {
+		if err := ctx.Err(); err != nil {
+			return dropped, err
+		}
+		if database.IsTemplate {
+			// template databases can't be dropped
+			if _, err := db.ExecContext(ctx, `ALTER DATABASE `+quoteIdentifier(database.Name)+` IS_TEMPLATE false;`); err != nil {
+				return dropped, fmt.Errorf("failed to drop the stale database %s: %w", database.Name, err)
+			}
+		}
+		if err := o.deleteDatabaseFunction(ctx, db, database.Name); err != nil {
+			return dropped, fmt.Errorf("failed to drop the stale database %s: %w", database.Name, err)
+		}
+		dropped = append(dropped, database.Name)
+	}
+	return dropped, nil
+}
+
+// TestDatabase is a test database found on the base server by ListTestDatabases.
+type TestDatabase struct {
+	Name string
+	// CreatedAt is the creation time recorded on the comment, zero when there is none.
+	CreatedAt  time.Time
+	IsTemplate bool
+}
+
+// ListTestDatabases returns the databases on the base server with the database
+// prefix, testing_db_ unless changed with WithDatabasePrefix, sorted by name,
+// including the ones being used by running tests. An empty base address uses the
+// default base server, see NewPostgresTest.
+func ListTestDatabases(ctx context.Context, baseAddress string, opts ...Option) ([]TestDatabase, error) {
+	if baseAddress != "" {
+		opts = append(append([]Option{}, opts...), WithFixedBaseAddress(baseAddress))
+	}
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	db, closeDB, err := o.openBaseDB()
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+	return listTestDatabases(ctx, o, db)
+}
+
+// databasePrefixPattern returns the LIKE pattern matching the names with the database prefix.
+func (o *options) databasePrefixPattern() string {
+	// the prefix is an identifier, only the underscores must be escaped
+	return strings.ReplaceAll(o.databasePrefix, "_", `\_`) + "%"
+}
+
+// listTestDatabases returns the databases with the database prefix.
+func listTestDatabases(ctx context.Context, o *options, db *sql.DB) ([]TestDatabase, error) {
+	rows, err := db.QueryContext(ctx, `SELECT datname, datistemplate, coalesce(shobj_description(oid, 'pg_database'), '')
+FROM pg_database
+WHERE datname LIKE $1
+ORDER BY datname;`, o.databasePrefixPattern())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var databases []TestDatabase
+	for rows.Next() {
+		var database TestDatabase
+		var comment string
+		if err := rows.Scan(&database.Name, &database.IsTemplate, &comment); err != nil {
+			return nil, err
+		}
+		database.CreatedAt, _ = parseCreatedAt(comment)
+		databases = append(databases, database)
+	}
+	return databases, rows.Err()
+}
@@ -0,0 +1,66 @@
+package postgrestest
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanupStaleDatabases(t *testing.T) {
+	t.Parallel()
+	// a prefix of its own, so the databases of the other tests aren't dropped
+	prefix := "testing_stale_"
+	stale := func(now time.Time) string {
+		ft := &fakeT{}
+		var dsn string
+		ft.run(func() {
+			dsn = NewPostgresTest(ft, WithDatabasePrefix(prefix), WithRetainOnFailure(),
+				WithClock(func() time.Time { return now }))
+		})
+		require.Empty(t, ft.failures())
+		// the failed test retains its database, like a crashed one
+		ft.Errorf("failed")
+		ft.runCleanups()
+		u, err := url.Parse(dsn)
+		require.NoError(t, err)
+		return strings.TrimPrefix(u.Path, "/")
+	}
+	old := stale(time.Now().Add(-48 * time.Hour))
+	recent := stale(time.Now())
+	// the databases of this process alive are kept, even when old
+	alive := Open(t, NewPostgresTest(t, WithDatabasePrefix(prefix),
+		WithClock(func() time.Time { return time.Now().Add(-48 * time.Hour) })))
+	databases, err := ListTestDatabases(context.Background(), testBaseAddress(), WithDatabasePrefix(prefix))
+	require.NoError(t, err)
+	names := make(map[string]TestDatabase)
+	for _, database := range databases {
+		names[database.Name] = database
+	}
+	require.Contains(t, names, old)
+	require.WithinDuration(t, time.Now().Add(-48*time.Hour), names[old].CreatedAt, time.Minute)
+	dropped, err := CleanupStaleDatabases(context.Background(), testBaseAddress(), 24*time.Hour, WithDatabasePrefix(prefix))
+	require.NoError(t, err)
+	require.Equal(t, []string{old}, dropped)
+	require.NoError(t, alive.Ping())
+	baseDB := Open(t, testBaseAddress())
+	var exists bool
+	require.NoError(t, baseDB.QueryRow(`SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1);`, recent).Scan(&exists))
+	require.True(t, exists)
+	require.NoError(t, ForceDeleteDatabaseFunction(baseDB, recent))
+}
+
+func TestParseCreatedAt(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2023, 7, 1, 12, 0, 0, 0, time.FixedZone("BRT", -3*60*60))
+	createdAt, ok := parseCreatedAt("owned by the billing team\n" + createdAtComment(now))
+	require.True(t, ok)
+	require.True(t, now.Equal(createdAt))
+	_, ok = parseCreatedAt("owned by the billing team")
+	require.False(t, ok)
+	_, ok = parseCreatedAt(createdAtPrefix + "yesterday")
+	require.False(t, ok)
+}
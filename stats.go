@@ -0,0 +1,125 @@
+package postgrestest
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// defaultStatementStatsLimit is the number of statements of the report of EnableStatementStats.
+const defaultStatementStatsLimit = 10
+
+// EnableStatementStats creates the pg_stat_statements extension on the test database,
+// resets its statistics, and logs a report of the statements executed on it with the
+// most total execution time when the test finishes, so the heavy queries introduced
+// by a change show up in the test output:
+//
+//	db := postgrestest.Open(t, postgrestest.NewPostgresTest(t))
+//	postgrestest.EnableStatementStats(t, db)
+//
+// The report covers the statements of all the connections to the test database, it
+// must be called after opening the connection so it's still open when the report runs.
+// The extension must be preloaded on the base server, with the shared_preload_libraries
+// setting like on docker-compose.yml, and requires Postgres 13 or later.
+// Like the other logs, the report is only printed for the failed tests unless the
+// tests run with -v.
+func EnableStatementStats(t TestingT, db *sql.DB, opts ...StatementStatsOption) {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	o := &statementStatsOptions{limit: defaultStatementStatsLimit}
+	for _, opt := range opts {
+		opt(o)
+	}
+	var preloaded string
+	require.NoError(t, db.QueryRow(`SHOW shared_preload_libraries;`).Scan(&preloaded))
+	if !strings.Contains(preloaded, "pg_stat_statements") {
+		require.Fail(t, "EnableStatementStats requires pg_stat_statements on the shared_preload_libraries of the base server")
+	}
+	var version int
+	require.NoError(t, db.QueryRow(`SHOW server_version_num;`).Scan(&version))
+	if version < 130000 {
+		require.Fail(t, "EnableStatementStats requires Postgres 13 or later", "the server version is %s", formatVersionNumber(version))
+	}
+	_, err := db.Exec(`CREATE EXTENSION IF NOT EXISTS pg_stat_statements;`)
+	require.NoError(t, err, "failed to create the pg_stat_statements extension")
+	// only the statistics of the test database are reset, other tests may be using theirs
+	_, err = db.Exec(`SELECT pg_stat_statements_reset(0, (SELECT oid FROM pg_database WHERE datname = current_database()), 0);`)
+	require.NoError(t, err, "failed to reset the statement statistics")
+	t.Cleanup(func() {
+		stats, err := statementStats(db, o.limit)
+		require.NoError(t, err, "postgrestest cleanup: failed to get the statement statistics")
+		if report := statementStatsReport(stats); report != "" {
+			logf(t, "%s", report)
+		}
+	})
+}
+
+// StatementStatsOption is an option for EnableStatementStats.
+type StatementStatsOption func(opts *statementStatsOptions)
+
+// statementStatsOptions holds the options of EnableStatementStats.
+type statementStatsOptions struct {
+	limit int
+}
+
+// WithStatementStatsLimit is an option that sets the number of statements on the
+// report of EnableStatementStats, 10 by default.
+func WithStatementStatsLimit(n int) StatementStatsOption {
+	return func(opts *statementStatsOptions) {
+		opts.limit = n
+	}
+}
+
+// statementStat is a statement of the pg_stat_statements view.
+type statementStat struct {
+	query     string
+	calls     int64
+	rows      int64
+	totalTime time.Duration
+}
+
+// statementStats returns the statements executed on the database with the most total
+// execution time, leaving out the ones reading the statistics.
+func statementStats(db *sql.DB, limit int) ([]statementStat, error) {
+	rows, err := db.Query(`SELECT query, calls, rows, total_exec_time
+FROM pg_stat_statements
+WHERE dbid = (SELECT oid FROM pg_database WHERE datname = current_database())
+	AND query NOT LIKE '%pg_stat_statements%'
+ORDER BY total_exec_time DESC, query
+LIMIT $1;`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var stats []statementStat
+	for rows.Next() {
+		var stat statementStat
+		var milliseconds float64
+		if err := rows.Scan(&stat.query, &stat.calls, &stat.rows, &milliseconds); err != nil {
+			return nil, err
+		}
+		stat.totalTime = time.Duration(milliseconds * float64(time.Millisecond))
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
+
+// statementStatsReport returns the report of the statements, empty when there are none.
+func statementStatsReport(stats []statementStat) string {
+	if len(stats) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "postgrestest: top %d statements by total execution time:", len(stats))
+	for _, stat := range stats {
+		// the statements are printed on a single line
+		fmt.Fprintf(&b, "\n\t%s total, %d calls, %d rows: %s", stat.totalTime, stat.calls, stat.rows, strings.Join(strings.Fields(stat.query), " "))
+	}
+	return b.String()
+}
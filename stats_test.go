@@ -0,0 +1,50 @@
+package postgrestest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnableStatementStats(t *testing.T) {
+	t.Parallel()
+	db := Open(t, NewPostgresTest(t))
+	var preloaded string
+	require.NoError(t, db.QueryRow(`SHOW shared_preload_libraries;`).Scan(&preloaded))
+	if !strings.Contains(preloaded, "pg_stat_statements") {
+		ft := &fakeT{}
+		ft.run(func() {
+			EnableStatementStats(ft, db)
+		})
+		require.Contains(t, ft.failures(), "EnableStatementStats requires pg_stat_statements")
+		return
+	}
+	ft := &fakeT{}
+	ft.run(func() {
+		EnableStatementStats(ft, db, WithStatementStatsLimit(1))
+	})
+	require.Empty(t, ft.failures())
+	_, err := db.Exec(`CREATE TABLE items (id int);`)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err := db.Exec(`INSERT INTO items SELECT generate_series(1, 10000);`)
+		require.NoError(t, err)
+	}
+	ft.runCleanups()
+	require.Empty(t, ft.failures())
+	require.Regexp(t, `postgrestest: top 1 statements by total execution time:\n\t.+ total, 3 calls, 30000 rows: INSERT INTO items SELECT generate_series\(\$1, \$2\)`, ft.logged())
+}
+
+func TestStatementStatsReport(t *testing.T) {
+	t.Parallel()
+	require.Empty(t, statementStatsReport(nil))
+	report := statementStatsReport([]statementStat{
+		{query: "SELECT *\n\tFROM users\n\tWHERE id = $1", calls: 2, rows: 2, totalTime: 1500 * time.Millisecond},
+		{query: "SELECT 1", calls: 1, rows: 1, totalTime: time.Millisecond},
+	})
+	require.Equal(t, "postgrestest: top 2 statements by total execution time:\n"+
+		"\t1.5s total, 2 calls, 2 rows: SELECT * FROM users WHERE id = $1\n"+
+		"\t1ms total, 1 calls, 1 rows: SELECT 1", report)
+}
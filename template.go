@@ -0,0 +1,54 @@
+package postgrestest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/stretchr/testify/require"
+)
+
+// NewTemplate creates a test database like NewPostgresTest, runs the seed on it, like
+// the migrations, and marks it as a template, returning its name. The test databases
+// created with WithTemplate and the name are clones of it, so the seed runs once for
+// all of them instead of once per test:
+//
+//	func TestOrders(t *testing.T) {
+//		template := postgrestest.NewTemplate(t, migrate)
+//		t.Run("create", func(t *testing.T) {
+//			db := postgrestest.Open(t, postgrestest.NewPostgresTest(t, postgrestest.WithTemplate(template)))
+//			// ...
+//		})
+//	}
+//
+// The seed connection is closed before returning, since Postgres doesn't clone
+// databases with open connections. The template is deleted on the test cleanup.
+func NewTemplate(t TestingT, seed func(db *sql.DB) error, opts ...Option) string {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	templateOpts, err := newOptions(append(append([]Option{}, opts...), func(opts *options) {
+		opts.setupFunctions = append(opts.setupFunctions, setupWithoutContext(seed))
+		opts.markAsTemplate = true
+	}))
+	require.NoError(t, err)
+	if templateOpts.engine == EngineSQLite {
+		require.NoError(t, errors.New("templates are not supported by the SQLite engine"))
+	}
+	databaseName, err := templateOpts.createDatabase(context.Background(), t)
+	require.NoError(t, err)
+	t.Cleanup(templateOpts.cleanupFunction(t, databaseName))
+	return databaseName
+}
+
+// WithTemplate is an option that creates the test database as a clone of the template
+// database, with CREATE DATABASE ... TEMPLATE, instead of the default template1.
+// The template can be created with NewTemplate, or be any database without other
+// connections that the role can clone.
+func WithTemplate(name string) Option {
+	return func(opts *options) {
+		opts.template = name
+	}
+}
@@ -0,0 +1,39 @@
+package postgrestest
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTemplate(t *testing.T) {
+	t.Parallel()
+	seeds := 0
+	template := NewTemplate(t, func(db *sql.DB) error {
+		seeds++
+		_, err := db.Exec(`CREATE TABLE items (id serial PRIMARY KEY, name text NOT NULL);
+INSERT INTO items (name) VALUES ('a'), ('b');`)
+		return err
+	})
+	for _, name := range []string{"first", "second"} {
+		t.Run(name, func(t *testing.T) {
+			db := Open(t, NewPostgresTest(t, WithTemplate(template)))
+			var count int
+			require.NoError(t, db.QueryRow(`SELECT count(*) FROM items;`).Scan(&count))
+			require.Equal(t, 2, count)
+			_, err := db.Exec(`INSERT INTO items (name) VALUES ('c');`)
+			require.NoError(t, err)
+		})
+	}
+	require.Equal(t, 1, seeds)
+}
+
+func TestWithTemplateOptions(t *testing.T) {
+	t.Parallel()
+	opts, err := newOptions([]Option{WithTemplate("testing_db_template")})
+	require.NoError(t, err)
+	require.Equal(t, `CREATE DATABASE "testing_db" TEMPLATE "testing_db_template"`, opts.createDatabaseStatement("testing_db"))
+	_, err = newOptions([]Option{WithTemplate("testing_db_template"), WithICULocale("en-US")})
+	require.ErrorContains(t, err, "the collation provider can't be used with WithTemplate")
+}
@@ -0,0 +1,15 @@
+schema "public" {
+}
+
+table "items" {
+  schema = schema.public
+  column "id" {
+    type = serial
+  }
+  column "name" {
+    type = text
+  }
+  primary_key {
+    columns = [column.id]
+  }
+}
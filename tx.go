@@ -0,0 +1,52 @@
+package postgrestest
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/stretchr/testify/require"
+)
+
+// NewPostgresTestTx creates a test database like NewPostgresTest and returns a
+// transaction on it, which is rolled back on cleanup. Statements on the transaction
+// see each other's effects, but other connections don't, so it only suits tests
+// whose code runs on the transaction.
+// Creating a database per test dominates the time of short tests, to share one
+// database between them, create it once and use NewTestTx on each test instead.
+func NewPostgresTestTx(t TestingT, opts ...Option) *sql.Tx {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	db := NewPostgresTestDB(t, opts...)
+	return NewTestTx(t, db)
+}
+
+// NewTestTx begins a transaction on the database and rolls it back on cleanup,
+// isolating the test from the other ones using the database in microseconds:
+//
+//	db := postgrestest.Open(t, postgrestest.NewPostgresTest(t, postgrestest.WithMigrations(migrations, "*.sql")))
+//	t.Run("create", func(t *testing.T) {
+//		tx := postgrestest.NewTestTx(t, db)
+//		// ...
+//	})
+//
+// The transaction holds one of the connections of the database until the test finishes.
+// Sequences advanced by the test are not rolled back, like on any transaction.
+func NewTestTx(t TestingT, db *sql.DB) *sql.Tx {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		// the test may have finished the transaction itself
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			require.NoError(t, err)
+		}
+	})
+	return tx
+}
@@ -0,0 +1,38 @@
+package postgrestest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPostgresTestTx(t *testing.T) {
+	t.Parallel()
+	tx := NewPostgresTestTx(t)
+	_, err := tx.Exec(`CREATE TABLE items (id serial PRIMARY KEY, name text NOT NULL);
+INSERT INTO items (name) VALUES ('a');`)
+	require.NoError(t, err)
+	var count int
+	require.NoError(t, tx.QueryRow(`SELECT count(*) FROM items;`).Scan(&count))
+	require.Equal(t, 1, count)
+}
+
+func TestNewTestTx(t *testing.T) {
+	t.Parallel()
+	db := Open(t, NewPostgresTest(t))
+	_, err := db.Exec(`CREATE TABLE items (id serial PRIMARY KEY, name text NOT NULL);`)
+	require.NoError(t, err)
+	for _, name := range []string{"a", "b"} {
+		t.Run(name, func(t *testing.T) {
+			tx := NewTestTx(t, db)
+			_, err := tx.Exec(`INSERT INTO items (name) VALUES ($1);`, name)
+			require.NoError(t, err)
+			var count int
+			require.NoError(t, tx.QueryRow(`SELECT count(*) FROM items;`).Scan(&count))
+			require.Equal(t, 1, count)
+		})
+	}
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM items;`).Scan(&count))
+	require.Equal(t, 0, count)
+}
@@ -0,0 +1,36 @@
+package postgrestest
+
+import (
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTestUser(t *testing.T) {
+	t.Parallel()
+	dsn := NewPostgresTest(t)
+	db := Open(t, dsn)
+	_, err := db.Exec(`CREATE TABLE items (id serial PRIMARY KEY, name text NOT NULL);
+CREATE TABLE secrets (value text NOT NULL);`)
+	require.NoError(t, err)
+	userDB := Open(t, CreateTestUser(t, dsn,
+		"SELECT, INSERT ON items",
+		"USAGE ON SEQUENCE items_id_seq",
+	))
+	_, err = userDB.Exec(`INSERT INTO items (name) VALUES ('a');`)
+	require.NoError(t, err)
+	_, err = userDB.Exec(`SELECT * FROM secrets;`)
+	var pgErr *pgconn.PgError
+	require.ErrorAs(t, err, &pgErr)
+	require.Equal(t, "42501", pgErr.Code)
+}
+
+func TestCreateTestUserKeyValueDSN(t *testing.T) {
+	t.Parallel()
+	ft := &fakeT{}
+	ft.run(func() {
+		CreateTestUser(ft, "host=localhost dbname=testing_db")
+	})
+	require.Contains(t, ft.failures(), "CreateTestUser requires an URL DSN")
+}
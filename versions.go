@@ -0,0 +1,92 @@
+package postgrestest
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ServerVersion returns the version of the server of the DSN, like 15.3, or 9.6.24
+// before Postgres 10.
+func ServerVersion(t TestingT, dsn string) string {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	return formatVersionNumber(serverVersionNumber(t, dsn))
+}
+
+// serverVersionNumber returns the server_version_num of the server of the DSN.
+func serverVersionNumber(t TestingT, dsn string) int {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	db, err := sql.Open(driverNameForDSN(dsn), dsn)
+	require.NoError(t, err)
+	defer db.Close()
+	// server_version may have a suffix like (Debian 15.3-1.pgdg120+1)
+	var number int
+	require.NoError(t, db.QueryRow(`SHOW server_version_num;`).Scan(&number), "failed to get the server version")
+	return number
+}
+
+// SkipIfVersionBelow skips the test when the version of the server of the DSN is lower
+// than the version, a major version like "15" or an exact one like "15.3", so the tests
+// using newer features, like MERGE, skip on older servers instead of failing with
+// syntax errors. The TestingT must have a Skipf method, like *testing.T.
+func SkipIfVersionBelow(t TestingT, dsn string, version string) {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	number, err := versionNumber(version)
+	require.NoError(t, err)
+	skipper, ok := t.(interface {
+		Skipf(format string, args ...interface{})
+	})
+	if !ok {
+		require.Fail(t, "SkipIfVersionBelow requires a TestingT with a Skipf method, like *testing.T")
+	}
+	if serverNumber := serverVersionNumber(t, dsn); serverNumber < number {
+		skipper.Skipf("postgrestest: the server version %s is below %s", formatVersionNumber(serverNumber), version)
+	}
+}
+
+// versionNumber returns the version as server_version_num, the missing parts are zero.
+func versionNumber(version string) (int, error) {
+	parts := strings.Split(version, ".")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid Postgres version %q, it must be like 15 or 15.3", version)
+	}
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return 0, fmt.Errorf("invalid Postgres version %q, it must be like 15 or 15.3", version)
+		}
+		numbers[i] = number
+	}
+	if numbers[0] >= 10 {
+		// since Postgres 10 the versions only have a major and a minor part
+		if len(parts) > 2 {
+			return 0, fmt.Errorf("invalid Postgres version %q, it must be like 15 or 15.3", version)
+		}
+		return numbers[0]*10000 + numbers[1], nil
+	}
+	return numbers[0]*10000 + numbers[1]*100 + numbers[2], nil
+}
+
+// formatVersionNumber returns the version of a server_version_num.
+func formatVersionNumber(number int) string {
+	if number >= 100000 {
+		return fmt.Sprintf("%d.%d", number/10000, number%10000)
+	}
+	return fmt.Sprintf("%d.%d.%d", number/10000, number/100%100, number%100)
+}
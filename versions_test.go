@@ -0,0 +1,42 @@
+package postgrestest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionNumber(t *testing.T) {
+	t.Parallel()
+	for version, want := range map[string]int{
+		"15":     150000,
+		"15.3":   150003,
+		"9.6":    90600,
+		"9.6.24": 90624,
+	} {
+		got, err := versionNumber(version)
+		require.NoError(t, err)
+		require.Equal(t, want, got, version)
+	}
+	for _, version := range []string{"", "latest", "15.3.1", "1.2.3.4", "15.-1"} {
+		_, err := versionNumber(version)
+		require.ErrorContains(t, err, "invalid Postgres version", version)
+	}
+	require.Equal(t, "15.3", formatVersionNumber(150003))
+	require.Equal(t, "9.6.24", formatVersionNumber(90624))
+}
+
+func TestSkipIfVersionBelow(t *testing.T) {
+	t.Parallel()
+	dsn := NewPostgresTest(t)
+	version := ServerVersion(t, dsn)
+	require.Regexp(t, `^[0-9]+\.[0-9]+(\.[0-9]+)?$`, version)
+	t.Run("same", func(t *testing.T) {
+		SkipIfVersionBelow(t, dsn, version)
+	})
+	skipped := t.Run("newer", func(t *testing.T) {
+		SkipIfVersionBelow(t, dsn, "99")
+		t.Error("the test wasn't skipped")
+	})
+	require.True(t, skipped)
+}
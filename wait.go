@@ -0,0 +1,82 @@
+package postgrestest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	// defaultRetryInterval is the first interval between the pings of the readiness wait.
+	defaultRetryInterval = 100 * time.Millisecond
+	// maxRetryInterval caps the interval between the pings as it doubles.
+	maxRetryInterval = 2 * time.Second
+)
+
+// WithWaitTimeout is an option that waits up to d for the base server to accept
+// connections before creating the test database, pinging it with exponential backoff.
+// It avoids spurious failures of the first tests when the server is still starting,
+// like a container started by the CI right before the tests.
+// By default there is no wait, the test fails if the server isn't ready.
+func WithWaitTimeout(d time.Duration) Option {
+	return func(opts *options) {
+		opts.waitTimeout = d
+	}
+}
+
+// WithRetryInterval is an option that sets the interval before the second ping of
+// the readiness wait enabled with WithWaitTimeout. The interval doubles after each
+// failed ping, up to 2s. The default is 100ms.
+func WithRetryInterval(d time.Duration) Option {
+	return func(opts *options) {
+		opts.retryInterval = d
+	}
+}
+
+// waitForBaseServer pings the base server until it accepts connections, when the
+// readiness wait is enabled.
+func (o *options) waitForBaseServer(ctx context.Context, db *sql.DB) error {
+	if o.waitTimeout <= 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, o.waitTimeout)
+	defer cancel()
+	interval := o.retryInterval
+	if interval <= 0 {
+		interval = defaultRetryInterval
+	}
+	if err := pingUntilReady(ctx, db, interval); err != nil {
+		return fmt.Errorf("the base server wasn't ready after %s: %w", o.waitTimeout, err)
+	}
+	return nil
+}
+
+// pingUntilReady pings the database until it accepts connections or the context is
+// done, doubling the interval between the pings up to maxRetryInterval. It returns
+// the error of the last ping.
+func pingUntilReady(ctx context.Context, db *sql.DB, interval time.Duration) error {
+	for {
+		pingCtx, cancel := context.WithTimeout(ctx, time.Second)
+		err := db.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(interval):
+		}
+		interval = nextRetryInterval(interval)
+	}
+}
+
+// nextRetryInterval returns the interval doubled, up to maxRetryInterval.
+func nextRetryInterval(interval time.Duration) time.Duration {
+	interval *= 2
+	if interval > maxRetryInterval {
+		return maxRetryInterval
+	}
+	return interval
+}
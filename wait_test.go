@@ -0,0 +1,32 @@
+package postgrestest
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithWaitTimeout(t *testing.T) {
+	t.Parallel()
+	// a port nothing listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := "postgres://postgres:root@" + listener.Addr().String()
+	require.NoError(t, listener.Close())
+	ft := &fakeT{}
+	start := time.Now()
+	ft.run(func() {
+		NewPostgresTest(ft, WithFixedBaseAddress(address), WithWaitTimeout(300*time.Millisecond), WithRetryInterval(10*time.Millisecond))
+	})
+	require.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+	require.Contains(t, ft.failures(), "the base server wasn't ready after 300ms")
+}
+
+func TestNextRetryInterval(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, 200*time.Millisecond, nextRetryInterval(100*time.Millisecond))
+	require.Equal(t, 2*time.Second, nextRetryInterval(1500*time.Millisecond))
+	require.Equal(t, 2*time.Second, nextRetryInterval(2*time.Second))
+}